@@ -0,0 +1,68 @@
+package api
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 500
+)
+
+func Register(r *router.Router[*core.RequestEvent]) {
+	g := r.Group("/api/orgtool")
+
+	g.GET("/ledger", listLedger).Bind(apis.RequireAuth("users"))
+	g.GET("/redemptions", listRedemptions).Bind(apis.RequireAuth("users"))
+	g.POST("/redemptions/{id}/refund", refundRedemption).Bind(apis.RequireAuth("users"))
+	g.POST("/rewards/{id}/redeem", redeemReward).Bind(apis.RequireAuth("users"))
+	g.POST("/rewards/{id}/use", useReward).Bind(apis.RequireAuth("users"))
+	g.POST("/rewards/{id}/save", saveReward).Bind(apis.RequireAuth("users"))
+	g.GET("/approvals", listApprovals).Bind(apis.RequireAuth("users"))
+	g.POST("/approvals/{id}/approve", approveApproval).Bind(apis.RequireAuth("users"))
+	g.POST("/approvals/{id}/reject", rejectApproval).Bind(apis.RequireAuth("users"))
+	g.GET("/groups/{id}/leaderboard", getLeaderboard).Bind(apis.RequireAuth("users"))
+	g.GET("/calendar/{file}", getCalendar)
+	g.GET("/export", exportData).Bind(apis.RequireAuth("users"))
+	g.GET("/crons", listCrons).Bind(apis.RequireSuperuserAuth())
+	g.POST("/entries/{id}/progress", incrementProgress).Bind(apis.RequireAuth("users"))
+}
+
+type page struct {
+	Page       int            `json:"page"`
+	PerPage    int            `json:"perPage"`
+	TotalItems int            `json:"totalItems"`
+	TotalPages int            `json:"totalPages"`
+	Items      []*core.Record `json:"items"`
+}
+
+func newPage(e *core.RequestEvent) page {
+	query := e.Request.URL.Query()
+
+	p, err := strconv.Atoi(query.Get("page"))
+	if err != nil || p < 1 {
+		p = 1
+	}
+
+	perPage, err := strconv.Atoi(query.Get("perPage"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	perPage = min(perPage, maxPerPage)
+
+	return page{Page: p, PerPage: perPage, Items: []*core.Record{}}
+}
+
+func (p *page) offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+func (p *page) setTotal(total int64) {
+	p.TotalItems = int(total)
+	p.TotalPages = int(math.Ceil(float64(total) / float64(p.PerPage)))
+}
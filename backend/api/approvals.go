@@ -0,0 +1,84 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/approvals"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+// listApprovals returns the approvals of the users supervised by the
+// authenticated user, the pending ones unless ?status= says otherwise.
+func listApprovals(e *core.RequestEvent) error {
+	result := newPage(e)
+
+	status := e.Request.URL.Query().Get("status")
+	if status == "" {
+		status = approvals.StatusPending
+	}
+
+	where := dbx.And(
+		dbx.HashExp{"status": status},
+		dbx.NewExp(
+			"user IN (SELECT users.id FROM users, json_each(users.supervisors) WHERE json_each.value = {:supervisor})",
+			dbx.Params{"supervisor": e.Auth.Id},
+		),
+	)
+
+	total, err := e.App.CountRecords(approvals.Collection, where)
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+	result.setTotal(total)
+
+	err = e.App.RecordQuery(approvals.Collection).
+		AndWhere(where).
+		OrderBy("created DESC", "id DESC").
+		Limit(int64(result.PerPage)).
+		Offset(int64(result.offset())).
+		All(&result.Items)
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+func approveApproval(e *core.RequestEvent) error {
+	approval, err := approvals.Approve(e.App, e.Request.PathValue("id"), e.Auth)
+	if err != nil {
+		return approvalError(e, err)
+	}
+
+	return e.JSON(http.StatusOK, approval)
+}
+
+func rejectApproval(e *core.RequestEvent) error {
+	approval, err := approvals.Reject(e.App, e.Request.PathValue("id"), e.Auth)
+	if err != nil {
+		return approvalError(e, err)
+	}
+
+	return e.JSON(http.StatusOK, approval)
+}
+
+func approvalError(e *core.RequestEvent, err error) error {
+	switch {
+	case errors.Is(err, approvals.ErrNotSupervisor):
+		return e.NotFoundError("", err)
+	case errors.Is(err, approvals.ErrDecided):
+		return e.Error(http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, rewards.ErrLimit), errors.Is(err, rewards.ErrNotEnoughPoints),
+		errors.Is(err, rewards.ErrMaxRedeemables):
+		return e.BadRequestError(err.Error(), nil)
+	case errors.Is(err, sql.ErrNoRows):
+		return e.NotFoundError("", err)
+	}
+
+	return e.InternalServerError("", err)
+}
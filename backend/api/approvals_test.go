@@ -0,0 +1,253 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/approvals"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+const (
+	testAwardApproval      = "testapproval001"
+	testRedemptionApproval = "testapproval002"
+)
+
+// newApprovalTestApp returns the fixture with the user supervised by the
+// partner and two pending approvals: an award of 4 points for the entry and a
+// redemption of one unit of the reward.
+func newApprovalTestApp(t testing.TB) *tests.TestApp {
+	app := newTestApp(t)
+
+	user, err := app.FindRecordById("users", testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.Set("requires_approval", true)
+	user.Set("supervisors", []string{testPartner})
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := app.FindRecordById("entries", testEntry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry.Set("pending_approval", true)
+	if err := app.Save(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	expires := time.Now().Add(approvals.Timeout)
+
+	testutil.NewRecord(t, app, approvals.Collection, testAwardApproval, map[string]any{
+		"user":       testUser,
+		"kind":       approvals.KindEntry,
+		"status":     approvals.StatusPending,
+		"expires_at": expires,
+		"entry":      testEntry,
+		"points":     4,
+	})
+
+	testutil.NewRecord(t, app, approvals.Collection, testRedemptionApproval, map[string]any{
+		"user":       testUser,
+		"kind":       approvals.KindRedemption,
+		"status":     approvals.StatusPending,
+		"expires_at": expires,
+		"reward":     testReward,
+		"quantity":   1,
+		"points":     3,
+	})
+
+	return app
+}
+
+// expectState checks the user points, whether the entry still waits and how
+// many times the reward was redeemed.
+func expectState(t testing.TB, app core.App, points int, pending bool, redeemed int) {
+	user, err := app.FindRecordById("users", testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.GetInt("points") != points {
+		t.Errorf("Expected %d points, got %d", points, user.GetInt("points"))
+	}
+
+	entry, err := app.FindRecordById("entries", testEntry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.GetBool("pending_approval") != pending {
+		t.Errorf("Expected the entry pending approval to be %v", pending)
+	}
+
+	reward, err := app.FindRecordById("rewards", testReward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reward.GetInt("redeemed") != redeemed {
+		t.Errorf("Expected the reward redeemed %d times, got %d", redeemed, reward.GetInt("redeemed"))
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	user := authToken(t, testUser)
+	supervisor := authToken(t, testPartner)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "supervisor lists the pending approvals",
+			Method:          http.MethodGet,
+			URL:             "/api/orgtool/approvals",
+			Headers:         map[string]string{"Authorization": supervisor},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"totalItems":2`},
+		},
+		{
+			Name:            "supervised user sees none",
+			Method:          http.MethodGet,
+			URL:             "/api/orgtool/approvals",
+			Headers:         map[string]string{"Authorization": user},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"totalItems":0`},
+		},
+		{
+			Name:            "approve award",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/approvals/" + testAwardApproval + "/approve",
+			Headers:         map[string]string{"Authorization": supervisor},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"status":"approved"`, `"decided_by":"` + testPartner + `"`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 14, false, 0)
+			},
+		},
+		{
+			Name:            "approve redemption",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/approvals/" + testRedemptionApproval + "/approve",
+			Headers:         map[string]string{"Authorization": supervisor},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"status":"approved"`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 7, true, 1)
+			},
+		},
+		{
+			Name:            "reject award",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/approvals/" + testAwardApproval + "/reject",
+			Headers:         map[string]string{"Authorization": supervisor},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"status":"rejected"`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 10, false, 0)
+			},
+		},
+		{
+			Name:            "reject redemption",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/approvals/" + testRedemptionApproval + "/reject",
+			Headers:         map[string]string{"Authorization": supervisor},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"status":"rejected"`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 10, true, 0)
+			},
+		},
+		{
+			Name:            "supervised user approves their own award",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/approvals/" + testAwardApproval + "/approve",
+			Headers:         map[string]string{"Authorization": user},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 10, true, 0)
+			},
+		},
+		{
+			Name:            "supervised user clears the pending approval",
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/entries/records/" + testEntry,
+			Body:            strings.NewReader(`{"pending_approval":false}`),
+			Headers:         map[string]string{"Authorization": user},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 10, true, 0)
+			},
+		},
+		{
+			Name:            "supervised user redeems without approval",
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/rewards/records/" + testReward,
+			Body:            strings.NewReader(`{"redeemed":1}`),
+			Headers:         map[string]string{"Authorization": user},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Redemptions need a supervisor approval, use the redeem action to request one."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 10, true, 0)
+			},
+		},
+		{
+			Name:            "approve an expired approval",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/approvals/" + testAwardApproval + "/approve",
+			Headers:         map[string]string{"Authorization": supervisor},
+			ExpectedStatus:  409,
+			ExpectedContent: []string{`"message":"` + approvals.ErrDecided.Error() + `."`},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				if _, err := approvals.Expire(app, time.Now().Add(approvals.Timeout+time.Minute)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectState(t, app, 10, false, 0)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.TestAppFactory = newApprovalTestApp
+		scenario.Test(t)
+	}
+}
+
+func TestApprovalsExpire(t *testing.T) {
+	app := newApprovalTestApp(t)
+	defer app.Cleanup()
+
+	// Nothing is due yet
+	if n, err := approvals.Expire(app, time.Now()); err != nil || n != 0 {
+		t.Fatalf("Expired %d approvals early, %v", n, err)
+	}
+
+	n, err := approvals.Expire(app, time.Now().Add(approvals.Timeout+time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("Expected 2 expired approvals, got %d", n)
+	}
+
+	for _, id := range []string{testAwardApproval, testRedemptionApproval} {
+		approval, err := app.FindRecordById(approvals.Collection, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if approval.GetString("status") != approvals.StatusExpired {
+			t.Errorf("Approval %s is %s", id, approval.GetString("status"))
+		}
+	}
+
+	// Neither paid nor redeemed, and the entry no longer waits
+	expectState(t, app, 10, false, 0)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/calendar"
+)
+
+// getCalendar serves the iCalendar feed of the open entries of the user
+// owning the token. Calendar apps can't authenticate, the token in the path
+// is the only credential.
+func getCalendar(e *core.RequestEvent) error {
+	token := strings.TrimSuffix(e.Request.PathValue("file"), ".ics")
+
+	record, err := e.App.FindFirstRecordByFilter(
+		calendar.TokensCollection,
+		"token = {:token}",
+		dbx.Params{"token": token},
+	)
+	if token == "" || err != nil {
+		return e.NotFoundError("", nil)
+	}
+
+	user, err := e.App.FindRecordById("users", record.GetString("user"))
+	if err != nil {
+		return e.NotFoundError("", nil)
+	}
+
+	todos, err := calendar.Todos(e.App, user)
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	name := record.GetString("name")
+	if name == "" {
+		name = "Activities"
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf, name, record.GetString("kind"), todos, time.Now()); err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	e.Response.Header().Set("Cache-Control", "private, max-age=300")
+
+	return e.Blob(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
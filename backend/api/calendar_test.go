@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/calendar"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+const testCalendarToken = "testcalendartoken0123456789abcdefghijklm"
+
+func newCalendarTestApp(t testing.TB) *tests.TestApp {
+	app := newTestApp(t)
+
+	testutil.NewRecord(t, app, calendar.TokensCollection, "testcalendar001", map[string]any{
+		"user":  testUser,
+		"token": testCalendarToken,
+		"kind":  calendar.KindTodo,
+	})
+
+	return app
+}
+
+func TestCalendarFeed(t *testing.T) {
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "feed",
+			URL:            "/api/orgtool/calendar/" + testCalendarToken + ".ics",
+			ExpectedStatus: 200,
+			ExpectedContent: []string{
+				"BEGIN:VCALENDAR\r\n",
+				"UID:" + testEntry + "@orgtool\r\n",
+				"SUMMARY:Run\r\n",
+				"END:VCALENDAR\r\n",
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+					t.Errorf("Content type is %q", ct)
+				}
+			},
+		},
+		{
+			Name:            "unknown token",
+			URL:             "/api/orgtool/calendar/" + strings.ToUpper(testCalendarToken) + ".ics",
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+		},
+		{
+			Name:            "revoked token",
+			URL:             "/api/orgtool/calendar/" + testCalendarToken + ".ics",
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				record, err := app.FindRecordById(calendar.TokensCollection, "testcalendar001")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := app.Delete(record); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodGet
+		scenario.TestAppFactory = newCalendarTestApp
+		scenario.Test(t)
+	}
+}
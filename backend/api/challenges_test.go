@@ -0,0 +1,285 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/challenges"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+const (
+	testChallenge      = "testchallenge01"
+	testLinkedActivity = "testactivity002"
+	testOtherActivity  = "testactivity003"
+	testParticipant    = "testparticipant"
+)
+
+// newChallengeTestApp returns the fixture with a challenge of the user starting
+// tomorrow, following the fixture activity with a stake of 2 points, and two
+// daily activities of the partner, only the first created from the template.
+func newChallengeTestApp(t testing.TB) *tests.TestApp {
+	app := newTestApp(t)
+
+	start := time.Now().Add(24 * time.Hour)
+	testutil.NewRecord(t, app, challenges.Collection, testChallenge, map[string]any{
+		"name":     "A week of running",
+		"owner":    testUser,
+		"type":     "daily",
+		"activity": testActivity,
+		"start":    start,
+		"end":      start.Add(7 * 24 * time.Hour),
+		"win_rule": challenges.WinNoneMissed,
+		"stake":    2,
+		"status":   challenges.StatusOpen,
+	})
+
+	for id, template := range map[string]string{testLinkedActivity: testActivity, testOtherActivity: ""} {
+		testutil.NewRecord(t, app, "activities", id, map[string]any{
+			"name":     "Run",
+			"user":     testPartner,
+			"type":     "daily",
+			"goal":     1,
+			"points":   1,
+			"template": template,
+		})
+	}
+
+	return app
+}
+
+// joinTestChallenge makes the partner join with the linked activity.
+func joinTestChallenge(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+	collection, err := app.FindCollectionByNameOrId(challenges.ParticipantsCollection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	participant := core.NewRecord(collection)
+	participant.Id = testParticipant
+	participant.Set("challenge", testChallenge)
+	participant.Set("user", testPartner)
+	participant.Set("activity", testLinkedActivity)
+	if err := challenges.Join(app, participant, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Save(participant); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expectStake checks the partner points, the challenge pot and how many
+// participants joined.
+func expectStake(t testing.TB, app core.App, points int, pot int, participants int) {
+	partner, err := app.FindRecordById("users", testPartner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partner.GetInt("points") != points {
+		t.Errorf("Expected %d points, got %d", points, partner.GetInt("points"))
+	}
+
+	challenge, err := app.FindRecordById(challenges.Collection, testChallenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge.GetInt("pot") != pot {
+		t.Errorf("Expected a pot of %d, got %d", pot, challenge.GetInt("pot"))
+	}
+
+	n, err := app.CountRecords(challenges.ParticipantsCollection, dbx.HashExp{"challenge": testChallenge})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(n) != participants {
+		t.Errorf("Expected %d participants, got %d", participants, n)
+	}
+}
+
+func TestJoinChallenge(t *testing.T) {
+	join := func(activity string) *strings.Reader {
+		return strings.NewReader(`{"challenge":"` + testChallenge + `","user":"` + testPartner + `","activity":"` + activity + `"}`)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "activity created from the template",
+			Body:            join(testLinkedActivity),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"activity":"` + testLinkedActivity + `"`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectStake(t, app, 3, 2, 1)
+			},
+		},
+		{
+			Name:            "activity of the type not following the template",
+			Body:            join(testOtherActivity),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + challenges.ErrActivity.Error() + `."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectStake(t, app, 5, 0, 0)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodPost
+		scenario.URL = "/api/collections/" + challenges.ParticipantsCollection + "/records"
+		scenario.Headers = map[string]string{"Authorization": authToken(t, testPartner)}
+		scenario.TestAppFactory = newChallengeTestApp
+		scenario.Test(t)
+	}
+}
+
+func TestChallengeTemplate(t *testing.T) {
+	create := func(typ string) *strings.Reader {
+		return strings.NewReader(`{"name":"Run","type":"` + typ + `","activity":"` + testActivity + `",` +
+			`"start":"2100-01-01 00:00:00.000Z","end":"2100-02-01 00:00:00.000Z","win_rule":"most_completed"}`)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "own activity",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/" + challenges.Collection + "/records",
+			Body:            create("daily"),
+			Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"activity":"` + testActivity + `"`},
+		},
+		{
+			Name:            "activity of another type",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/" + challenges.Collection + "/records",
+			Body:            create("weekly"),
+			Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + challenges.ErrTemplate.Error() + `."`},
+		},
+		{
+			Name:            "activity of another user",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/" + challenges.Collection + "/records",
+			Body:            create("daily"),
+			Headers:         map[string]string{"Authorization": authToken(t, testPartner)},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + challenges.ErrTemplate.Error() + `."`},
+		},
+		{
+			Name:            "changed once joined",
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/" + challenges.Collection + "/records/" + testChallenge,
+			Body:            strings.NewReader(`{"activity":"` + testLinkedActivity + `"}`),
+			Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Cannot change the template of a joined challenge."`},
+			BeforeTestFunc:  joinTestChallenge,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				challenge, err := app.FindRecordById(challenges.Collection, testChallenge)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if challenge.GetString("activity") != testActivity {
+					t.Errorf("The template changed to %q", challenge.GetString("activity"))
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.TestAppFactory = newChallengeTestApp
+		scenario.Test(t)
+	}
+}
+
+func TestChallengeParticipantRules(t *testing.T) {
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "participant leaves before the start",
+			Method:         http.MethodDelete,
+			Headers:        map[string]string{"Authorization": authToken(t, testPartner)},
+			ExpectedStatus: 204,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectStake(t, app, 5, 0, 0)
+			},
+		},
+		{
+			Name:           "owner removes a participant",
+			Method:         http.MethodDelete,
+			Headers:        map[string]string{"Authorization": authToken(t, testUser)},
+			ExpectedStatus: 204,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectStake(t, app, 5, 0, 0)
+			},
+		},
+		{
+			Name:            "participant leaves after the start",
+			Method:          http.MethodDelete,
+			Headers:         map[string]string{"Authorization": authToken(t, testPartner)},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + challenges.ErrStarted.Error() + `."`},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				joinTestChallenge(t, app, e)
+
+				challenge, err := app.FindRecordById(challenges.Collection, testChallenge)
+				if err != nil {
+					t.Fatal(err)
+				}
+				challenge.Set("start", types.NowDateTime().Add(-time.Hour))
+				if err := app.Save(challenge); err != nil {
+					t.Fatal(err)
+				}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectStake(t, app, 3, 2, 1)
+			},
+		},
+		{
+			Name:            "another user removes a participant",
+			Method:          http.MethodDelete,
+			Headers:         map[string]string{"Authorization": authToken(t, testMember)},
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectStake(t, app, 3, 2, 1)
+			},
+		},
+		{
+			Name:            "participant changes their counters",
+			Method:          http.MethodPatch,
+			Body:            strings.NewReader(`{"completed":10}`),
+			Headers:         map[string]string{"Authorization": authToken(t, testPartner)},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				participant, err := app.FindRecordById(challenges.ParticipantsCollection, testParticipant)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if participant.GetInt("completed") != 0 {
+					t.Errorf("The counters changed to %d completed", participant.GetInt("completed"))
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.URL = "/api/collections/" + challenges.ParticipantsCollection + "/records/" + testParticipant
+		scenario.TestAppFactory = func(t testing.TB) *tests.TestApp {
+			app := newChallengeTestApp(t)
+			if scenario.BeforeTestFunc == nil {
+				joinTestChallenge(t, app, nil)
+			}
+
+			return app
+		}
+		scenario.Test(t)
+	}
+}
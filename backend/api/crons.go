@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/cron"
+)
+
+func listCrons(e *core.RequestEvent) error {
+	version, jobs, err := cron.Active(e.App)
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	if jobs == nil {
+		jobs = []cron.Job{}
+	}
+
+	return e.JSON(http.StatusOK, map[string]any{
+		"version": version,
+		"jobs":    jobs,
+	})
+}
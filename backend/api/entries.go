@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/groups"
+)
+
+type progressBody struct {
+	Delta int `json:"delta"`
+}
+
+func incrementProgress(e *core.RequestEvent) error {
+	var body progressBody
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("Invalid request body", err)
+	}
+
+	if body.Delta == 0 {
+		return e.BadRequestError("The progress delta cannot be zero", nil)
+	}
+
+	entry, err := e.App.FindRecordById(entries.Collection, e.Request.PathValue("id"))
+	if err != nil {
+		return e.NotFoundError("", err)
+	}
+
+	activity, err := e.App.FindRecordById("activities", entry.GetString("activity"))
+	if err != nil || !groups.CanAccess(e.App, activity, e.Auth.Id) {
+		return e.NotFoundError("", err)
+	}
+
+	entry, err = entries.Increment(e.App, entry.Id, e.Auth, body.Delta)
+	switch {
+	case errors.Is(err, entries.ErrClosed):
+		return e.BadRequestError(err.Error(), nil)
+	case errors.Is(err, entries.ErrConflict):
+		return e.Error(http.StatusConflict, err.Error(), nil)
+	case err != nil:
+		return e.InternalServerError("", err)
+	}
+
+	return e.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestEntryServerFieldsAreProtected(t *testing.T) {
+	token := authToken(t, testUser)
+
+	for _, body := range []string{
+		`{"goal":1}`,
+		`{"period_end":"2099-01-01 00:00:00.000Z"}`,
+		`{"period_start":"2000-01-01 00:00:00.000Z"}`,
+		`{"missed":true}`,
+		`{"closed":true}`,
+	} {
+		scenario := tests.ApiScenario{
+			Name:            body,
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/entries/records/" + testEntry,
+			Body:            strings.NewReader(body),
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0, "OnRecordUpdateRequest": 1},
+			TestAppFactory:  newTestApp,
+		}
+		scenario.Test(t)
+	}
+}
+
+func TestProgressOnlyThroughEndpoint(t *testing.T) {
+	token := authToken(t, testUser)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "patch",
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/entries/records/" + testEntry,
+			Body:            strings.NewReader(`{"progress":2}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+			ExpectedEvents:  map[string]int{"*": 0, "OnRecordUpdateRequest": 1},
+		},
+		{
+			Name:            "increment",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/entries/" + testEntry + "/progress",
+			Body:            strings.NewReader(`{"delta":3}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"progress":3`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				n, err := app.CountRecords("progress_events")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != 1 {
+					t.Fatalf("Expected 1 progress event, got %d", n)
+				}
+
+				expectProgressEvent(t, app, 3, 3)
+			},
+		},
+		{
+			Name:            "decrement below zero",
+			Method:          http.MethodPost,
+			URL:             "/api/orgtool/entries/" + testEntry + "/progress",
+			Body:            strings.NewReader(`{"delta":-5}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"progress":0`},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				entry, err := app.FindRecordById("entries", testEntry)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				entry.Set("progress", 2)
+				if err := app.Save(entry); err != nil {
+					t.Fatal(err)
+				}
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				// Only the progress there was is taken away
+				expectProgressEvent(t, app, -2, 0)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Headers = map[string]string{"Authorization": token}
+		scenario.TestAppFactory = newTestApp
+		scenario.Test(t)
+	}
+}
+
+// expectProgressEvent checks the delta and the progress recorded by the only
+// progress event of the fixture entry.
+func expectProgressEvent(t testing.TB, app core.App, delta int, progress int) {
+	event, err := app.FindFirstRecordByData("progress_events", "entry", testEntry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.GetInt("delta") != delta || event.GetInt("progress") != progress {
+		t.Errorf(
+			"Expected a delta of %d to %d, got %d to %d",
+			delta, progress, event.GetInt("delta"), event.GetInt("progress"),
+		)
+	}
+}
+
+func TestCompletedByIsSetByServer(t *testing.T) {
+	scenario := tests.ApiScenario{
+		Method:          http.MethodPatch,
+		URL:             "/api/collections/entries/records/" + testEntry,
+		Body:            strings.NewReader(`{"completed_by":"` + testPartner + `"}`),
+		Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+		ExpectedStatus:  200,
+		ExpectedContent: []string{`"completed_by":"` + testUser + `"`},
+		TestAppFactory:  newTestApp,
+		BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			entry, err := app.FindRecordById("entries", testEntry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entry.Set("progress", 2)
+			entry.Set("completed_by", testUser)
+			if err := app.Save(entry); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+	scenario.Test(t)
+}
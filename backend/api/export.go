@@ -0,0 +1,40 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/export"
+)
+
+// exportData returns the zip archive with the data of the authenticated
+// user, the files being in the ?format= (csv or json, the default).
+func exportData(e *core.RequestEvent) error {
+	format := e.Request.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatJSON
+	}
+
+	if !slices.Contains(export.Formats, format) {
+		return e.BadRequestError("Unknown export format", nil)
+	}
+
+	now := time.Now()
+
+	// Buffered so a failure can still be reported with its status
+	var buf bytes.Buffer
+	if err := export.Write(e.App, &buf, e.Auth, format, now); err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	e.Response.Header().Set(
+		"Content-Disposition",
+		`attachment; filename="`+export.FileName(e.Auth, now)+`"`,
+	)
+
+	return e.Blob(http.StatusOK, "application/zip", buf.Bytes())
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/export"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+const (
+	testPartnerActivity = "testactivity004"
+	testPartnerReward   = "testreward00002"
+)
+
+// newExportTestApp returns the fixture with a unit of the reward redeemed and
+// an activity and a reward of the partner, to be left out of the export.
+func newExportTestApp(t testing.TB) *tests.TestApp {
+	app := newTestApp(t)
+
+	if _, _, err := rewards.Redeem(app, testReward, testUser, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.NewRecord(t, app, "activities", testPartnerActivity, map[string]any{
+		"name":   "Swim",
+		"user":   testPartner,
+		"type":   "daily",
+		"goal":   1,
+		"points": 1,
+	})
+
+	testutil.NewRecord(t, app, "rewards", testPartnerReward, map[string]any{
+		"name":            "Cinema",
+		"user":            testPartner,
+		"unit_cost":       4,
+		"max_redeemables": 1,
+		"reset_period":    "never",
+	})
+
+	return app
+}
+
+// readExport unzips the response, returning the files by name in the order
+// they were written.
+func readExport(t testing.TB, res *http.Response) ([]string, map[string][]byte) {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, f.Name)
+		files[f.Name] = content
+	}
+
+	return names, files
+}
+
+// expectExport checks the archive headers, layout and manifest, and that
+// nothing of the partner is in it.
+func expectExport(t testing.TB, res *http.Response, format string) map[string][]byte {
+	if res.Header.Get("Content-Type") != "application/zip" ||
+		!strings.Contains(res.Header.Get("Content-Disposition"), "orgtool-export-"+testUser+"-") {
+		t.Errorf("Exported %q as %q", res.Header.Get("Content-Type"), res.Header.Get("Content-Disposition"))
+	}
+
+	names, files := readExport(t, res)
+
+	tables := []string{"activities"}
+	for _, typ := range period.Types {
+		tables = append(tables, typ+"_entries")
+	}
+	tables = append(tables, "rewards", "redemptions", "point_transactions")
+
+	var expected []string
+	for _, table := range tables {
+		expected = append(expected, table+"."+format)
+	}
+	expected = append(expected, export.ManifestFile)
+
+	if !slices.Equal(names, expected) {
+		t.Fatalf("Expected the files %v, got %v", expected, names)
+	}
+
+	var manifest export.Manifest
+	if err := json.Unmarshal(files[export.ManifestFile], &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.SchemaVersion != export.SchemaVersion || manifest.Format != format || manifest.User != testUser {
+		t.Errorf("Manifest of version %d, format %q and user %q", manifest.SchemaVersion, manifest.Format, manifest.User)
+	}
+
+	// The entry opened with the activity next to the fixture one, the award
+	// and the redemption in the ledger
+	records := map[string]int{
+		"activities":         1,
+		"daily_entries":      2,
+		"rewards":            1,
+		"redemptions":        1,
+		"point_transactions": 2,
+	}
+	for i, file := range manifest.Files {
+		if file.Name != expected[i] || file.Records != records[tables[i]] || len(file.Fields) == 0 {
+			t.Errorf("Manifest lists %s with %d records, expected %s with %d", file.Name, file.Records, expected[i], records[tables[i]])
+		}
+	}
+
+	for name, content := range files {
+		for _, id := range []string{testPartner, testPartnerActivity, testPartnerReward} {
+			if bytes.Contains(content, []byte(id)) {
+				t.Errorf("The partner %s is in %s", id, name)
+			}
+		}
+	}
+
+	return files
+}
+
+func TestExport(t *testing.T) {
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "json",
+			URL:             "/api/orgtool/export",
+			ExpectedContent: []string{"activities.json", export.ManifestFile},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				files := expectExport(t, res, export.FormatJSON)
+
+				var activities []map[string]any
+				if err := json.Unmarshal(files["activities.json"], &activities); err != nil {
+					t.Fatal(err)
+				}
+				if len(activities) != 1 || activities[0]["id"] != testActivity || activities[0]["name"] != "Run" {
+					t.Errorf("Exported activities %v", activities)
+				}
+
+				var entries []map[string]any
+				if err := json.Unmarshal(files["daily_entries.json"], &entries); err != nil {
+					t.Fatal(err)
+				}
+				ids := []any{}
+				for _, entry := range entries {
+					ids = append(ids, entry["id"])
+					if entry["activity"] != testActivity || entry["period_type"] != period.Daily {
+						t.Errorf("Exported daily entry %v", entry)
+					}
+				}
+				if !slices.Contains(ids, testEntry) {
+					t.Errorf("The fixture entry isn't in %v", ids)
+				}
+
+				var transactions []map[string]any
+				if err := json.Unmarshal(files["point_transactions.json"], &transactions); err != nil {
+					t.Fatal(err)
+				}
+				for _, transaction := range transactions {
+					if transaction["user"] != testUser {
+						t.Errorf("Exported a transaction of %v", transaction["user"])
+					}
+				}
+			},
+		},
+		{
+			Name:            "csv",
+			URL:             "/api/orgtool/export?format=csv",
+			ExpectedContent: []string{"activities.csv", export.ManifestFile},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				files := expectExport(t, res, export.FormatCSV)
+
+				rows, err := csv.NewReader(bytes.NewReader(files["redemptions.csv"])).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) != 2 {
+					t.Fatalf("Expected the header and a redemption, got %d rows", len(rows))
+				}
+
+				redemption := map[string]string{}
+				for i, field := range rows[0] {
+					redemption[field] = rows[1][i]
+				}
+				if redemption["reward"] != testReward || redemption["quantity"] != "1" || redemption["cost"] != "3" {
+					t.Errorf("Exported redemption %v", redemption)
+				}
+
+				rows, err = csv.NewReader(bytes.NewReader(files["weekly_entries.csv"])).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) != 1 || !slices.Contains(rows[0], "period_start") {
+					t.Errorf("Expected only the header of the weekly entries, got %v", rows)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodGet
+		scenario.Headers = map[string]string{"Authorization": authToken(t, testUser)}
+		scenario.ExpectedStatus = 200
+		scenario.TestAppFactory = newExportTestApp
+		scenario.Test(t)
+	}
+
+	guest := tests.ApiScenario{
+		Name:            "guest",
+		Method:          http.MethodGet,
+		URL:             "/api/orgtool/export",
+		ExpectedStatus:  401,
+		ExpectedContent: []string{`"data":{}`},
+		TestAppFactory:  newExportTestApp,
+	}
+	guest.Test(t)
+}
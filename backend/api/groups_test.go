@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/groups"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+const testGroup = "testgroup000001"
+
+// newGroupTestApp shares the fixture activity and reward with a group owned by
+// the fixture user, the partner being an admin and the third user a member.
+func newGroupTestApp(t testing.TB) *tests.TestApp {
+	app := newTestApp(t)
+	addTestGroup(t, app)
+
+	return app
+}
+
+func addTestGroup(t testing.TB, app core.App) {
+	testutil.NewRecord(t, app, groups.Collection, testGroup, map[string]any{
+		"name":  "Home",
+		"owner": testUser,
+	})
+	if _, err := groups.AddMember(app, testGroup, testPartner, groups.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := groups.AddMember(app, testGroup, testMember, groups.RoleMember); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range []struct{ collection, id string }{
+		{"activities", testActivity},
+		{"rewards", testReward},
+	} {
+		record, err := app.FindRecordById(item.collection, item.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record.Set("group", testGroup)
+		if err := app.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGroupRewardRoles(t *testing.T) {
+	roles := []struct {
+		role   string
+		user   string
+		points int
+		manage bool
+	}{
+		{groups.RoleOwner, testUser, 10, true},
+		{groups.RoleAdmin, testPartner, 5, true},
+		{groups.RoleMember, testMember, 5, false},
+	}
+
+	for _, r := range roles {
+		token := authToken(t, r.user)
+
+		redeem := tests.ApiScenario{
+			Name:            r.role + " redeems",
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/rewards/records/" + testReward,
+			Body:            strings.NewReader(`{"redeemed":1}`),
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"redeemed":1`},
+			TestAppFactory:  newGroupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindRecordById("users", r.user)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if points := user.GetInt("points"); points != r.points-3 {
+					t.Errorf("Expected %d points left, got %d", r.points-3, points)
+				}
+			},
+		}
+		redeem.Test(t)
+
+		rename := tests.ApiScenario{
+			Name:           r.role + " renames",
+			Method:         http.MethodPatch,
+			URL:            "/api/collections/rewards/records/" + testReward,
+			Body:           strings.NewReader(`{"name":"Cake"}`),
+			Headers:        map[string]string{"Authorization": token},
+			ExpectedStatus: 200,
+			TestAppFactory: newGroupTestApp,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				reward, err := app.FindRecordById("rewards", testReward)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if renamed := reward.GetString("name") == "Cake"; renamed != r.manage {
+					t.Errorf("Expected the reward renamed to be %v", r.manage)
+				}
+			},
+		}
+		if r.manage {
+			rename.ExpectedContent = []string{`"name":"Cake"`}
+		} else {
+			rename.ExpectedStatus = 400
+			rename.ExpectedContent = []string{`"data":{}`}
+		}
+		rename.Test(t)
+
+		remove := tests.ApiScenario{
+			Name:           r.role + " deletes",
+			Method:         http.MethodDelete,
+			URL:            "/api/collections/rewards/records/" + testReward,
+			Headers:        map[string]string{"Authorization": token},
+			ExpectedStatus: 204,
+			TestAppFactory: newGroupTestApp,
+		}
+		if !r.manage {
+			remove.ExpectedStatus = 400
+			remove.ExpectedContent = []string{`"data":{}`}
+		}
+		remove.Test(t)
+	}
+}
+
+func TestGroupRewardRequiresAuth(t *testing.T) {
+	scenario := tests.ApiScenario{
+		Method:          http.MethodPatch,
+		URL:             "/api/collections/rewards/records/" + testReward,
+		Body:            strings.NewReader(`{"redeemed":1}`),
+		ExpectedStatus:  404,
+		ExpectedContent: []string{`"data":{}`},
+		TestAppFactory:  newGroupTestApp,
+	}
+	scenario.Test(t)
+}
+
+func TestGroupMemberReadsEntries(t *testing.T) {
+	token := authToken(t, testMember)
+
+	for _, collection := range []string{"entries", "daily_entries"} {
+		scenarios := []tests.ApiScenario{
+			{
+				Name:            collection + " of the group",
+				TestAppFactory:  newGroupTestApp,
+				ExpectedStatus:  200,
+				ExpectedContent: []string{`"id":"` + testEntry + `"`},
+			},
+			{
+				Name:            collection + " of another user",
+				TestAppFactory:  newTestApp,
+				ExpectedStatus:  404,
+				ExpectedContent: []string{`"data":{}`},
+			},
+		}
+
+		for _, scenario := range scenarios {
+			scenario.URL = "/api/collections/" + collection + "/records/" + testEntry
+			scenario.Headers = map[string]string{"Authorization": token}
+			scenario.Test(t)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/groups"
+	"github.com/dr4ghs/orgtool/leaderboard"
+)
+
+type leaderboardResult struct {
+	Group  string         `json:"group"`
+	Window string         `json:"window"`
+	Items  []*core.Record `json:"items"`
+}
+
+// getLeaderboard returns the cached scores of a group the user is a member
+// of, for the ?window= (week, month or all, the default).
+func getLeaderboard(e *core.RequestEvent) error {
+	groupId := e.Request.PathValue("id")
+	if !groups.IsMember(e.App, groupId, e.Auth.Id) {
+		return e.NotFoundError("", nil)
+	}
+
+	window := e.Request.URL.Query().Get("window")
+	if window == "" {
+		window = leaderboard.WindowAll
+	}
+
+	if !slices.Contains(leaderboard.Windows, window) {
+		return e.BadRequestError("Unknown leaderboard window", nil)
+	}
+
+	params := dbx.Params{"group": groupId, "window": window}
+
+	// Groups are refreshed once a period closes, fill the ones never refreshed
+	cached, err := e.App.CountRecords(leaderboard.Collection, dbx.HashExp{"group": groupId})
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	if cached == 0 {
+		if err := leaderboard.Refresh(e.App, groupId, time.Now()); err != nil {
+			return e.InternalServerError("", err)
+		}
+	}
+
+	items, err := e.App.FindRecordsByFilter(
+		leaderboard.Collection,
+		"group = {:group} && window = {:window}",
+		"rank,user",
+		0,
+		0,
+		params,
+	)
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	return e.JSON(http.StatusOK, leaderboardResult{Group: groupId, Window: window, Items: items})
+}
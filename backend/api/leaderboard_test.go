@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/leaderboard"
+)
+
+// expectScores checks the cached all time scores of the test group, as user,
+// points and rank.
+func expectScores(t testing.TB, app core.App, expected map[string][2]int) {
+	scores, err := app.FindAllRecords(
+		leaderboard.Collection,
+		dbx.HashExp{"group": testGroup, "window": leaderboard.WindowAll},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scores) != len(expected) {
+		t.Fatalf("Expected %d scores, got %d", len(expected), len(scores))
+	}
+
+	for _, score := range scores {
+		got := [2]int{score.GetInt("points"), score.GetInt("rank")}
+		if want, ok := expected[score.GetString("user")]; !ok || got != want {
+			t.Errorf("User %s scored %v, expected %v", score.GetString("user"), got, want)
+		}
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	url := "/api/orgtool/groups/" + testGroup + "/leaderboard"
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "ranks with ties",
+			URL:             url,
+			Headers:         map[string]string{"Authorization": authToken(t, testMember)},
+			TestAppFactory:  newGroupTestApp,
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"window":"all"`, `"user":"` + testUser + `"`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectScores(t, app, map[string][2]int{
+					testUser:    {10, 1},
+					testPartner: {5, 2},
+					testMember:  {5, 2},
+				})
+			},
+		},
+		{
+			Name:            "opted out member",
+			URL:             url,
+			Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+			TestAppFactory:  newGroupTestApp,
+			BeforeTestFunc:  updateRecord("users", testMember, map[string]any{"leaderboard_opt_out": true}),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"user":"` + testPartner + `"`},
+			NotExpectedContent: []string{
+				`"user":"` + testMember + `"`,
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectScores(t, app, map[string][2]int{
+					testUser:    {10, 1},
+					testPartner: {5, 2},
+				})
+			},
+		},
+		{
+			Name:            "unknown window",
+			URL:             url + "?window=year",
+			Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+			TestAppFactory:  newGroupTestApp,
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+		},
+		{
+			Name:            "not a member",
+			URL:             url,
+			Headers:         map[string]string{"Authorization": authToken(t, testMember)},
+			TestAppFactory:  newTestApp,
+			ExpectedStatus:  404,
+			ExpectedContent: []string{`"data":{}`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodGet
+		scenario.Test(t)
+	}
+}
+
+func TestLeaderboardAfterApproval(t *testing.T) {
+	scenario := tests.ApiScenario{
+		Method:         http.MethodPost,
+		URL:            "/api/orgtool/approvals/" + testAwardApproval + "/approve",
+		Headers:        map[string]string{"Authorization": authToken(t, testPartner)},
+		ExpectedStatus: 200,
+		TestAppFactory: func(t testing.TB) *tests.TestApp {
+			app := newApprovalTestApp(t)
+			addTestGroup(t, app)
+
+			if err := leaderboard.Refresh(app, testGroup, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+
+			return app
+		},
+		ExpectedContent: []string{`"status":"approved"`},
+		AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			expectScores(t, app, map[string][2]int{
+				testUser:    {14, 1},
+				testPartner: {5, 2},
+				testMember:  {5, 2},
+			})
+		},
+	}
+	scenario.Test(t)
+}
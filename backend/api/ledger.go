@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func listLedger(e *core.RequestEvent) error {
+	result := newPage(e)
+
+	total, err := e.App.CountRecords("point_transactions", dbx.HashExp{"user": e.Auth.Id})
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+	result.setTotal(total)
+
+	result.Items, err = e.App.FindRecordsByFilter(
+		"point_transactions",
+		"user = {:user}",
+		"-created,-id",
+		result.PerPage,
+		result.offset(),
+		dbx.Params{"user": e.Auth.Id},
+	)
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestLedgerRequiresUser(t *testing.T) {
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "anonymous",
+			ExpectedStatus:  401,
+			ExpectedContent: []string{`"data":{}`},
+		},
+		{
+			Name:            "superuser",
+			Headers:         map[string]string{"Authorization": superuserToken(t)},
+			ExpectedStatus:  403,
+			ExpectedContent: []string{`"data":{}`},
+		},
+		{
+			Name:            "user",
+			Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"totalItems":1`, `"delta":10`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodGet
+		scenario.URL = "/api/orgtool/ledger"
+		scenario.TestAppFactory = newTestApp
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+// limitTestApp returns a factory of the fixture with the reward limits set,
+// the user in the timezone and the given units redeemed at each time.
+func limitTestApp(limits map[string]any, timezone string, redeemed map[time.Time]int) func(testing.TB) *tests.TestApp {
+	return func(t testing.TB) *tests.TestApp {
+		app := newTestApp(t)
+
+		updateRecord(rewards.Collection, testReward, limits)(t, app, nil)
+		updateRecord("users", testUser, map[string]any{"timezone": timezone})(t, app, nil)
+
+		for at, quantity := range redeemed {
+			testutil.NewRecord(t, app, rewards.RedemptionsCollection, "", map[string]any{
+				"reward":      testReward,
+				"user":        testUser,
+				"quantity":    quantity,
+				"cost":        3 * quantity,
+				"redeemed_at": at,
+			})
+		}
+
+		return app
+	}
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format(types.DefaultDateLayout)
+}
+
+func TestRewardLimits(t *testing.T) {
+	now := time.Now()
+	last := now.Add(-10 * time.Minute).Truncate(time.Millisecond)
+
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	week, weekEnd, err := period.Clock{Location: la, DayStart: period.DefaultDayStart}.Bounds(period.Weekly, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cooldown := limitTestApp(map[string]any{"cooldown": 60}, "", map[time.Time]int{last: 1})
+	stock := limitTestApp(map[string]any{"stock": 2}, "", map[time.Time]int{last: 2})
+	lastWeek := limitTestApp(
+		map[string]any{"weekly_cap": 1},
+		la.String(),
+		map[time.Time]int{week.Add(-time.Minute): 1},
+	)
+	thisWeek := limitTestApp(
+		map[string]any{"weekly_cap": 1},
+		la.String(),
+		map[time.Time]int{week.Add(time.Minute): 1},
+	)
+
+	redeem := "/api/orgtool/rewards/" + testReward + "/redeem"
+	view := "/api/collections/rewards/records/" + testReward
+	list := "/api/collections/rewards/records"
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "redeem on cooldown",
+			Method:          http.MethodPost,
+			URL:             redeem,
+			TestAppFactory:  cooldown,
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Reward limit reached: the reward is on cooldown until `},
+		},
+		{
+			Name:            "next available after the cooldown",
+			URL:             view,
+			TestAppFactory:  cooldown,
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"next_available_at":"` + formatDate(last.Add(time.Hour)) + `"`},
+		},
+		{
+			Name:            "redeem after the cooldown",
+			Method:          http.MethodPost,
+			URL:             redeem,
+			TestAppFactory:  limitTestApp(map[string]any{"cooldown": 5}, "", map[time.Time]int{last: 1}),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"balance":7`},
+		},
+		{
+			Name:            "redeem out of stock",
+			Method:          http.MethodPost,
+			URL:             redeem,
+			TestAppFactory:  stock,
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Reward limit reached: only 0 units of the reward are left in stock."`},
+		},
+		{
+			Name:            "next available out of stock",
+			URL:             list,
+			TestAppFactory:  stock,
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"next_available_at":""`},
+		},
+		{
+			Name:           "redeem a refunded stock",
+			Method:         http.MethodPost,
+			URL:            redeem,
+			TestAppFactory: stock,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				redemption, err := app.FindFirstRecordByData(rewards.RedemptionsCollection, "reward", testReward)
+				if err != nil {
+					t.Fatal(err)
+				}
+				redemption.Set("refunded", 1)
+				if err := app.Save(redemption); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"balance":7`},
+		},
+		{
+			Name:            "redeem with the cap used last week",
+			Method:          http.MethodPost,
+			URL:             redeem,
+			TestAppFactory:  lastWeek,
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"balance":7`},
+		},
+		{
+			Name:            "next available with the cap used last week",
+			URL:             list,
+			TestAppFactory:  lastWeek,
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"next_available_at":"` + formatDate(now)[:10]},
+		},
+		{
+			Name:            "redeem with the cap used this week",
+			Method:          http.MethodPost,
+			URL:             redeem,
+			TestAppFactory:  thisWeek,
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Reward limit reached: the weekly cap is 1 units and only 0 are left this week."`},
+		},
+		{
+			Name:            "next available with the cap used this week",
+			URL:             view,
+			TestAppFactory:  thisWeek,
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"next_available_at":"` + formatDate(weekEnd) + `"`},
+		},
+		{
+			Name:            "list with the cap used this week",
+			URL:             list,
+			TestAppFactory:  thisWeek,
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"next_available_at":"` + formatDate(weekEnd) + `"`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		if scenario.Method == "" {
+			scenario.Method = http.MethodGet
+		}
+		scenario.Headers = map[string]string{"Authorization": authToken(t, testUser)}
+		scenario.Body = strings.NewReader(`{}`)
+		scenario.Test(t)
+	}
+}
+
+func TestNextAvailableAll(t *testing.T) {
+	app := newTestApp(t)
+	defer app.Cleanup()
+
+	now := time.Now()
+	last := now.Add(-10 * time.Minute).Truncate(time.Millisecond)
+
+	limits := []map[string]any{
+		{},
+		{"cooldown": 60},
+		{"stock": 1},
+		{"weekly_cap": 2},
+	}
+
+	var records []*core.Record
+	for i, fields := range limits {
+		fields["name"] = "Reward"
+		fields["user"] = testUser
+		fields["unit_cost"] = 1
+		fields["max_redeemables"] = 10
+		reward := testutil.NewRecord(t, app, rewards.Collection, "", fields)
+
+		// One more unit each time
+		for range i {
+			testutil.NewRecord(t, app, rewards.RedemptionsCollection, "", map[string]any{
+				"reward":      reward.Id,
+				"user":        testUser,
+				"quantity":    1,
+				"cost":        1,
+				"redeemed_at": last,
+			})
+		}
+
+		records = append(records, reward)
+	}
+
+	all, err := rewards.NextAvailableAll(app, records, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindRecordById("users", testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reward := range records {
+		next, err := rewards.NextAvailable(app, period.UserClock(user), reward, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !all[reward.Id].Equal(next) {
+			t.Errorf("Reward %v: batched %s, alone %s", reward.Get("cooldown"), all[reward.Id], next)
+		}
+	}
+
+	if !all[records[1].Id].Equal(last.Add(time.Hour)) || !all[records[2].Id].IsZero() {
+		t.Errorf("Unexpected next times %v", all)
+	}
+}
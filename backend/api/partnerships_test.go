@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+// newPartnershipTestApp shares the fixture activity with the partner.
+func newPartnershipTestApp(t testing.TB) *tests.TestApp {
+	app := newTestApp(t)
+
+	testutil.NewRecord(t, app, "partnerships", "testpartnership", map[string]any{
+		"owner":      testUser,
+		"partner":    testPartner,
+		"activities": []string{testActivity},
+	})
+
+	return app
+}
+
+func TestPartnerReadsEntries(t *testing.T) {
+	closeEntry := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		entry, err := app.FindRecordById("entries", testEntry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Set("closed", true)
+		if err := app.Save(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, collection := range []string{"entries", "daily_entries"} {
+		scenarios := []tests.ApiScenario{
+			{
+				Name:            collection + " of the partner",
+				Headers:         map[string]string{"Authorization": authToken(t, testPartner)},
+				ExpectedStatus:  200,
+				ExpectedContent: []string{`"id":"` + testEntry + `"`},
+			},
+			{
+				Name:            collection + " closed of the partner",
+				Headers:         map[string]string{"Authorization": authToken(t, testPartner)},
+				BeforeTestFunc:  closeEntry,
+				ExpectedStatus:  200,
+				ExpectedContent: []string{`"id":"` + testEntry + `"`, `"closed":true`},
+			},
+			{
+				Name:            collection + " of another user",
+				Headers:         map[string]string{"Authorization": authToken(t, testMember)},
+				ExpectedStatus:  404,
+				ExpectedContent: []string{`"data":{}`},
+			},
+			{
+				Name:            collection + " as guest",
+				ExpectedStatus:  404,
+				ExpectedContent: []string{`"data":{}`},
+			},
+		}
+
+		for _, scenario := range scenarios {
+			scenario.URL = "/api/collections/" + collection + "/records/" + testEntry
+			scenario.TestAppFactory = newPartnershipTestApp
+			scenario.Test(t)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+// listRedemptions returns the redemption history of the authenticated user,
+// optionally narrowed by ?reward=, ?from= and ?to= (RFC 3339 or YYYY-MM-DD).
+func listRedemptions(e *core.RequestEvent) error {
+	result := newPage(e)
+	query := e.Request.URL.Query()
+
+	where := []dbx.Expression{dbx.HashExp{"user": e.Auth.Id}}
+
+	if reward := query.Get("reward"); reward != "" {
+		where = append(where, dbx.HashExp{"reward": reward})
+	}
+
+	for param, op := range map[string]string{"from": ">=", "to": "<"} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+
+		t, err := parseDate(value)
+		if err != nil {
+			return e.BadRequestError("Invalid '"+param+"' date", err)
+		}
+
+		where = append(where, dbx.NewExp(
+			"redeemed_at "+op+" {:"+param+"}",
+			dbx.Params{param: t.UTC().Format(types.DefaultDateLayout)},
+		))
+	}
+
+	total, err := e.App.CountRecords(rewards.RedemptionsCollection, dbx.And(where...))
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+	result.setTotal(total)
+
+	err = e.App.RecordQuery(rewards.RedemptionsCollection).
+		AndWhere(dbx.And(where...)).
+		OrderBy("redeemed_at DESC", "id DESC").
+		Limit(int64(result.PerPage)).
+		Offset(int64(result.offset())).
+		All(&result.Items)
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+type redemptionResult struct {
+	Redemption *core.Record `json:"redemption"`
+	Balance    int          `json:"balance"`
+}
+
+func refundRedemption(e *core.RequestEvent) error {
+	body := quantityBody{Quantity: 1}
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("Invalid request body", err)
+	}
+
+	redemption, err := e.App.FindRecordById(rewards.RedemptionsCollection, e.Request.PathValue("id"))
+	if err != nil || redemption.GetString("user") != e.Auth.Id {
+		return e.NotFoundError("", err)
+	}
+
+	redemption, user, err := rewards.Refund(e.App, redemption.Id, body.Quantity)
+	if err != nil {
+		return rewardActionError(e, err)
+	}
+
+	return e.JSON(http.StatusOK, redemptionResult{Redemption: redemption, Balance: user.GetInt("points")})
+}
+
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
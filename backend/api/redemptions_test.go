@@ -0,0 +1,246 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+const testRedemption = "testredemption1"
+
+// newRefundTestApp returns the fixture with two units of the reward redeemed
+// for 6 points, refundable for a day.
+func newRefundTestApp(t testing.TB) *tests.TestApp {
+	app := newTestApp(t)
+
+	reward, err := app.FindRecordById(rewards.Collection, testReward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reward.Set("refund_window", rewards.DefaultRefundWindow)
+	reward.Set("redeemed", 2)
+	if err := app.Save(reward); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := app.FindRecordById("users", testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.Record(app, user, -6, ledger.ReasonRedemption, reward); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.NewRecord(t, app, rewards.RedemptionsCollection, testRedemption, map[string]any{
+		"reward":      testReward,
+		"user":        testUser,
+		"quantity":    2,
+		"cost":        6,
+		"redeemed_at": time.Now(),
+	})
+
+	return app
+}
+
+// expectRefund checks the user points and locked points, and the reward
+// counter and savings.
+func expectRefund(t testing.TB, app core.App, points int, locked int, redeemed int, saved int) {
+	user, err := app.FindRecordById("users", testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.GetInt("points") != points || user.GetInt("locked_points") != locked {
+		t.Errorf(
+			"Expected %d points with %d locked, got %d with %d",
+			points, locked, user.GetInt("points"), user.GetInt("locked_points"),
+		)
+	}
+
+	reward, err := app.FindRecordById(rewards.Collection, testReward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reward.GetInt("redeemed") != redeemed || reward.GetInt("saved") != saved {
+		t.Errorf(
+			"Expected %d redeemed with %d saved, got %d with %d",
+			redeemed, saved, reward.GetInt("redeemed"), reward.GetInt("saved"),
+		)
+	}
+}
+
+func updateRecord(collection string, id string, fields map[string]any) func(testing.TB, *tests.TestApp, *core.ServeEvent) {
+	return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		record, err := app.FindRecordById(collection, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record.Load(fields)
+		if err := app.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRefundRedemption(t *testing.T) {
+	url := "/api/orgtool/redemptions/" + testRedemption + "/refund"
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "one unit by default",
+			Body:            strings.NewReader(`{}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"refunded":1`, `"balance":7`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectRefund(t, app, 7, 0, 1, 0)
+			},
+		},
+		{
+			Name:            "all the units",
+			Body:            strings.NewReader(`{"quantity":2}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"refunded":2`, `"balance":10`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectRefund(t, app, 10, 0, 0, 0)
+			},
+		},
+		{
+			Name:            "more than unused",
+			Body:            strings.NewReader(`{"quantity":3}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + rewards.ErrNotRefundable.Error() + `."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectRefund(t, app, 4, 0, 2, 0)
+			},
+		},
+		{
+			Name:            "window over",
+			Body:            strings.NewReader(`{}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + rewards.ErrRefundWindow.Error() + `."`},
+			BeforeTestFunc: updateRecord(rewards.RedemptionsCollection, testRedemption, map[string]any{
+				"redeemed_at": time.Now().Add(-25 * time.Hour),
+			}),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectRefund(t, app, 4, 0, 2, 0)
+			},
+		},
+		{
+			Name:            "refunds disabled",
+			Body:            strings.NewReader(`{}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + rewards.ErrRefundWindow.Error() + `."`},
+			BeforeTestFunc: updateRecord(rewards.Collection, testReward, map[string]any{
+				"refund_window": 0,
+			}),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectRefund(t, app, 4, 0, 2, 0)
+			},
+		},
+		{
+			Name:            "paid with the savings",
+			Body:            strings.NewReader(`{"quantity":2}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"refunded":2`, `"balance":10`},
+			BeforeTestFunc: updateRecord(rewards.RedemptionsCollection, testRedemption, map[string]any{
+				"saved": 4,
+			}),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				// The points are back, 4 of them locked in the reward again
+				expectRefund(t, app, 10, 4, 0, 4)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodPost
+		scenario.URL = url
+		scenario.Headers = map[string]string{"Authorization": authToken(t, testUser)}
+		scenario.TestAppFactory = newRefundTestApp
+		scenario.Test(t)
+	}
+
+	other := tests.ApiScenario{
+		Name:            "redemption of another user",
+		Method:          http.MethodPost,
+		URL:             url,
+		Headers:         map[string]string{"Authorization": authToken(t, testPartner)},
+		ExpectedStatus:  404,
+		ExpectedContent: []string{`"data":{}`},
+		TestAppFactory:  newRefundTestApp,
+		AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			expectRefund(t, app, 4, 0, 2, 0)
+		},
+	}
+	other.Test(t)
+}
+
+func TestRefundWindowDefault(t *testing.T) {
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "default",
+			Body:            strings.NewReader(`{"name":"Cake","user":"` + testUser + `","unit_cost":1,"max_redeemables":1}`),
+			ExpectedContent: []string{`"refund_window":24`},
+		},
+		{
+			Name:            "disabled",
+			Body:            strings.NewReader(`{"name":"Cake","user":"` + testUser + `","unit_cost":1,"max_redeemables":1,"refund_window":0}`),
+			ExpectedContent: []string{`"refund_window":0`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodPost
+		scenario.URL = "/api/collections/rewards/records"
+		scenario.Headers = map[string]string{"Authorization": authToken(t, testUser)}
+		scenario.ExpectedStatus = 200
+		scenario.TestAppFactory = newTestApp
+		scenario.Test(t)
+	}
+}
+
+func TestUseRedemptionsOfTheWindow(t *testing.T) {
+	app := newRefundTestApp(t)
+	defer app.Cleanup()
+
+	// Redeemed before the reset, it belongs to the previous window
+	old := testutil.NewRecord(t, app, rewards.RedemptionsCollection, "", map[string]any{
+		"reward":      testReward,
+		"user":        testUser,
+		"quantity":    1,
+		"cost":        3,
+		"redeemed_at": time.Now().Add(-48 * time.Hour),
+	})
+
+	reward, err := app.FindRecordById(rewards.Collection, testReward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reward.Set("last_reset", time.Now().Add(-24*time.Hour))
+	if err := app.Save(reward); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rewards.Use(app, testReward, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if used := testutil.Reload(t, app, old).GetInt("used"); used != 0 {
+		t.Errorf("Used %d units of the previous window", used)
+	}
+
+	redemption, err := app.FindRecordById(rewards.RedemptionsCollection, testRedemption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := redemption.GetInt("used"); used != 2 {
+		t.Errorf("Expected 2 units used in the window, got %d", used)
+	}
+}
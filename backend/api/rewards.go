@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/approvals"
+	"github.com/dr4ghs/orgtool/groups"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+type quantityBody struct {
+	Quantity int `json:"quantity"`
+}
+
+type amountBody struct {
+	Amount int `json:"amount"`
+}
+
+type rewardResult struct {
+	Reward    *core.Record `json:"reward"`
+	Balance   int          `json:"balance"`
+	Available int          `json:"available"`
+}
+
+// rewardResponse replies with the reward, enriched like the records API does,
+// and the owner balance.
+func rewardResponse(e *core.RequestEvent, reward *core.Record, user *core.Record) error {
+	if err := apis.EnrichRecord(e, reward); err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	return e.JSON(http.StatusOK, rewardResult{
+		Reward:    reward,
+		Balance:   user.GetInt("points"),
+		Available: rewards.Available(user),
+	})
+}
+
+// rewardQuantity reads the requested quantity, one when omitted, and makes
+// sure the reward belongs to the authenticated user or one of their groups.
+func rewardQuantity(e *core.RequestEvent) (*core.Record, int, error) {
+	body := quantityBody{Quantity: 1}
+	if err := e.BindBody(&body); err != nil {
+		return nil, 0, e.BadRequestError("Invalid request body", err)
+	}
+
+	reward, err := e.App.FindRecordById(rewards.Collection, e.Request.PathValue("id"))
+	if err != nil || !groups.CanAccess(e.App, reward, e.Auth.Id) {
+		return nil, 0, e.NotFoundError("", err)
+	}
+
+	return reward, body.Quantity, nil
+}
+
+func rewardActionError(e *core.RequestEvent, err error) error {
+	switch {
+	case errors.Is(err, rewards.ErrQuantity),
+		errors.Is(err, rewards.ErrMaxRedeemables),
+		errors.Is(err, rewards.ErrNotEnoughPoints),
+		errors.Is(err, rewards.ErrAllUsed),
+		errors.Is(err, rewards.ErrNotRefundable),
+		errors.Is(err, rewards.ErrRefundWindow),
+		errors.Is(err, rewards.ErrAmount),
+		errors.Is(err, rewards.ErrNotEnoughFree),
+		errors.Is(err, rewards.ErrNotEnoughSaved),
+		errors.Is(err, rewards.ErrLimit):
+		return e.BadRequestError(err.Error(), nil)
+	}
+
+	return e.InternalServerError("", err)
+}
+
+func redeemReward(e *core.RequestEvent) error {
+	reward, quantity, err := rewardQuantity(e)
+	if err != nil {
+		return err
+	}
+
+	// Supervised users only ask for the redemption
+	if approvals.Required(e.Auth) {
+		approval, err := approvals.RequestRedemption(e.App, e.Auth, reward, quantity)
+		if err != nil {
+			return rewardActionError(e, err)
+		}
+
+		return e.JSON(http.StatusAccepted, approval)
+	}
+
+	reward, user, err := rewards.Redeem(e.App, reward.Id, e.Auth.Id, quantity)
+	if err != nil {
+		return rewardActionError(e, err)
+	}
+
+	return rewardResponse(e, reward, user)
+}
+
+func useReward(e *core.RequestEvent) error {
+	reward, quantity, err := rewardQuantity(e)
+	if err != nil {
+		return err
+	}
+
+	reward, err = rewards.Use(e.App, reward.Id, quantity)
+	if err != nil {
+		return rewardActionError(e, err)
+	}
+
+	user, err := e.App.FindRecordById("users", reward.GetString("user"))
+	if err != nil {
+		return e.InternalServerError("", err)
+	}
+
+	return rewardResponse(e, reward, user)
+}
+
+// saveReward moves points into the reward savings, or back out of them with a
+// negative amount.
+func saveReward(e *core.RequestEvent) error {
+	var body amountBody
+	if err := e.BindBody(&body); err != nil {
+		return e.BadRequestError("Invalid request body", err)
+	}
+
+	reward, err := e.App.FindRecordById(rewards.Collection, e.Request.PathValue("id"))
+	if err != nil || reward.GetString("user") != e.Auth.Id {
+		return e.NotFoundError("", err)
+	}
+
+	reward, user, err := rewards.Save(e.App, reward.Id, body.Amount)
+	if err != nil {
+		return rewardActionError(e, err)
+	}
+
+	return rewardResponse(e, reward, user)
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+func TestRedeemThroughUpdate(t *testing.T) {
+	token := authToken(t, testUser)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "enough points",
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/rewards/records/" + testReward,
+			Body:            strings.NewReader(`{"redeemed":2}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"redeemed":2`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				user, err := app.FindRecordById("users", testUser)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if points := user.GetInt("points"); points != 4 {
+					t.Errorf("Expected 4 points left, got %d", points)
+				}
+
+				n, err := app.CountRecords("redemptions", dbx.HashExp{"reward": testReward, "quantity": 2})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != 1 {
+					t.Errorf("Expected the redemption to be recorded, got %d", n)
+				}
+			},
+		},
+		{
+			Name:            "not enough points",
+			Method:          http.MethodPatch,
+			URL:             "/api/collections/rewards/records/" + testReward,
+			Body:            strings.NewReader(`{"redeemed":4}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"Not enough points to redeem reward."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				n, err := app.CountRecords("point_transactions", dbx.HashExp{"user": testUser})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != 1 {
+					t.Errorf("Expected only the initial award in the ledger, got %d transactions", n)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Headers = map[string]string{"Authorization": token}
+		scenario.TestAppFactory = newTestApp
+		scenario.Test(t)
+	}
+}
+
+func TestRestartedAppRejectsOverRedemption(t *testing.T) {
+	token := authToken(t, testUser)
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "over the max redeemables",
+			Body:            strings.NewReader(`{"redeemed":6}`),
+			Method:          http.MethodPatch,
+			ExpectedContent: []string{`"message":"Redeemed rewards exceded the max redeemables limit."`},
+		},
+		{
+			Name:            "over the points",
+			Body:            strings.NewReader(`{"redeemed":4}`),
+			Method:          http.MethodPatch,
+			ExpectedContent: []string{`"message":"Not enough points to redeem reward."`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.URL = "/api/collections/rewards/records/" + testReward
+		scenario.Headers = map[string]string{"Authorization": token}
+		scenario.ExpectedStatus = 400
+		scenario.ExpectedContent = append(scenario.ExpectedContent, `"data":{}`)
+		scenario.TestAppFactory = newRestartedTestApp
+		scenario.AfterTestFunc = func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			reward, err := app.FindRecordById("rewards", testReward)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reward.GetInt("redeemed") != 0 {
+				t.Errorf("The reward was redeemed %d times", reward.GetInt("redeemed"))
+			}
+
+			user, err := app.FindRecordById("users", testUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.GetInt("points") != 10 {
+				t.Errorf("Points changed to %d", user.GetInt("points"))
+			}
+		}
+		scenario.Test(t)
+	}
+}
+
+// expectReward checks the user points, the ledger length and the reward
+// counters.
+func expectReward(t testing.TB, app core.App, points int, transactions int, redeemed int, used int) {
+	user, err := app.FindRecordById("users", testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.GetInt("points") != points {
+		t.Errorf("Expected %d points, got %d", points, user.GetInt("points"))
+	}
+
+	n, err := app.CountRecords("point_transactions", dbx.HashExp{"user": testUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(n) != transactions {
+		t.Errorf("Expected %d transactions, got %d", transactions, n)
+	}
+
+	reward, err := app.FindRecordById(rewards.Collection, testReward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reward.GetInt("redeemed") != redeemed || reward.GetInt("used") != used {
+		t.Errorf(
+			"Expected %d redeemed and %d used, got %d and %d",
+			redeemed, used, reward.GetInt("redeemed"), reward.GetInt("used"),
+		)
+	}
+}
+
+func failOn(event func(app core.App) *hook.TaggedHook[*core.RecordEvent]) func(testing.TB, *tests.TestApp, *core.ServeEvent) {
+	return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		event(app).BindFunc(func(e *core.RecordEvent) error {
+			return errors.New("Forced failure")
+		})
+	}
+}
+
+func TestRedeemAction(t *testing.T) {
+	url := "/api/orgtool/rewards/" + testReward + "/redeem"
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "one unit by default",
+			Body:            strings.NewReader(`{}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"redeemed":1`, `"balance":7`, `"available":7`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 7, 2, 1, 0)
+			},
+		},
+		{
+			Name:            "quantity",
+			Body:            strings.NewReader(`{"quantity":3}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"redeemed":3`, `"balance":1`, `"available":1`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 1, 2, 3, 0)
+
+				redemption, err := app.FindFirstRecordByData(rewards.RedemptionsCollection, "reward", testReward)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if redemption.GetInt("quantity") != 3 || redemption.GetInt("cost") != 9 {
+					t.Errorf("Redemption of %d units for %d points", redemption.GetInt("quantity"), redemption.GetInt("cost"))
+				}
+			},
+		},
+		{
+			Name:            "zero quantity",
+			Body:            strings.NewReader(`{"quantity":0}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + rewards.ErrQuantity.Error() + `."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 10, 1, 0, 0)
+			},
+		},
+		{
+			Name:            "insufficient balance",
+			Body:            strings.NewReader(`{"quantity":4}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + rewards.ErrNotEnoughPoints.Error() + `."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 10, 1, 0, 0)
+			},
+		},
+		{
+			Name:            "over the max redeemables",
+			Body:            strings.NewReader(`{"quantity":6}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + rewards.ErrMaxRedeemables.Error() + `."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 10, 1, 0, 0)
+			},
+		},
+		{
+			Name:            "failure after the debit",
+			Body:            strings.NewReader(`{"quantity":2}`),
+			ExpectedStatus:  500,
+			ExpectedContent: []string{`"data":{}`},
+			BeforeTestFunc: failOn(func(app core.App) *hook.TaggedHook[*core.RecordEvent] {
+				return app.OnRecordCreate(rewards.RedemptionsCollection)
+			}),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 10, 1, 0, 0)
+
+				n, err := app.CountRecords(rewards.RedemptionsCollection)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != 0 {
+					t.Errorf("Expected no redemption, got %d", n)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodPost
+		scenario.URL = url
+		scenario.Headers = map[string]string{"Authorization": authToken(t, testUser)}
+		scenario.TestAppFactory = newTestApp
+		scenario.Test(t)
+	}
+
+	other := tests.ApiScenario{
+		Name:            "reward of another user",
+		Method:          http.MethodPost,
+		URL:             url,
+		Headers:         map[string]string{"Authorization": authToken(t, testPartner)},
+		ExpectedStatus:  404,
+		ExpectedContent: []string{`"data":{}`},
+		TestAppFactory:  newTestApp,
+		AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			expectReward(t, app, 10, 1, 0, 0)
+		},
+	}
+	other.Test(t)
+}
+
+func TestUseAction(t *testing.T) {
+	redeem := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		if _, _, err := rewards.Redeem(app, testReward, testUser, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "one unit by default",
+			Body:            strings.NewReader(`{}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"used":1`, `"redeemed":2`, `"balance":4`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 4, 2, 2, 1)
+			},
+		},
+		{
+			Name:            "quantity",
+			Body:            strings.NewReader(`{"quantity":2}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"used":2`, `"balance":4`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 4, 2, 2, 2)
+			},
+		},
+		{
+			Name:            "more than redeemed",
+			Body:            strings.NewReader(`{"quantity":3}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + rewards.ErrAllUsed.Error() + `."`},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 4, 2, 2, 0)
+			},
+		},
+		{
+			Name:            "failure after marking the redemptions",
+			Body:            strings.NewReader(`{"quantity":1}`),
+			ExpectedStatus:  500,
+			ExpectedContent: []string{`"data":{}`},
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				redeem(t, app, e)
+				failOn(func(app core.App) *hook.TaggedHook[*core.RecordEvent] {
+					return app.OnRecordUpdate(rewards.Collection)
+				})(t, app, e)
+			},
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				expectReward(t, app, 4, 2, 2, 0)
+
+				n, err := app.CountRecords(rewards.RedemptionsCollection, dbx.NewExp("used > 0"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != 0 {
+					t.Errorf("Expected no used redemption, got %d", n)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Method = http.MethodPost
+		scenario.URL = "/api/orgtool/rewards/" + testReward + "/use"
+		scenario.Headers = map[string]string{"Authorization": authToken(t, testUser)}
+		scenario.TestAppFactory = newTestApp
+		if scenario.BeforeTestFunc == nil {
+			scenario.BeforeTestFunc = redeem
+		}
+		scenario.Test(t)
+	}
+}
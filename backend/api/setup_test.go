@@ -0,0 +1,135 @@
+package api
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+// Fixture ids, fixed so the scenarios can reference them before the app is
+// created.
+const (
+	testUser     = "testuser0000001"
+	testPartner  = "testuser0000002"
+	testMember   = "testuser0000003"
+	testActivity = "testactivity001"
+	testEntry    = "testentry000001"
+	testReward   = "testreward00001"
+	testAdmin    = "testsuperuser01"
+)
+
+// newTestApp returns an app with the hooks and routes bound and the fixture
+// created: a user with 10 points owning a daily activity with an open entry
+// and a reward, two other users with 5 points and a superuser.
+func newTestApp(t testing.TB) *tests.TestApp {
+	app := bootTestApp(t)
+
+	user := testutil.NewUser(t, app, testUser, "user@example.com", 10)
+	testutil.NewUser(t, app, testPartner, "partner@example.com", 5)
+	testutil.NewUser(t, app, testMember, "member@example.com", 5)
+
+	superusers, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	superuser := core.NewRecord(superusers)
+	superuser.Id = testAdmin
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	superuser.SetTokenKey(testAdmin + "-token-key-0123456789")
+	if err := app.Save(superuser); err != nil {
+		t.Fatal(err)
+	}
+
+	activity := testutil.NewRecord(t, app, "activities", testActivity, map[string]any{
+		"name":   "Run",
+		"user":   user.Id,
+		"type":   "daily",
+		"goal":   2,
+		"points": 5,
+	})
+
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	testutil.NewRecord(t, app, "entries", testEntry, map[string]any{
+		"activity":     activity.Id,
+		"period_type":  "daily",
+		"period_start": start,
+		"period_end":   start.Add(24 * time.Hour),
+		"goal":         2,
+	})
+
+	testutil.NewRecord(t, app, "rewards", testReward, map[string]any{
+		"name":            "Ice cream",
+		"user":            user.Id,
+		"unit_cost":       3,
+		"max_redeemables": 5,
+		"reset_period":    "never",
+	})
+
+	return app
+}
+
+// newRestartedTestApp returns an app booted on a copy of the newTestApp data,
+// its migrations already applied like after a restart.
+func newRestartedTestApp(t testing.TB) *tests.TestApp {
+	first := newTestApp(t)
+	defer first.Cleanup()
+
+	// Closes the database so the copy has everything written
+	first.ResetBootstrapState()
+
+	return bootTestApp(t, first.DataDir())
+}
+
+// bootTestApp binds the hooks and routes the way main does on every boot.
+func bootTestApp(t testing.TB, dataDir ...string) *tests.TestApp {
+	app := testutil.NewApp(t, dataDir...)
+	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		Register(e.Router)
+		return e.Next()
+	})
+
+	return app
+}
+
+// authToken returns an auth token of the fixture user, as the scenarios need
+// the headers before their app exists.
+func authToken(t testing.TB, userId string) string {
+	return recordToken(t, "users", userId)
+}
+
+func superuserToken(t testing.TB) string {
+	return recordToken(t, core.CollectionNameSuperusers, testAdmin)
+}
+
+// tokens caches the issued tokens by collection and id, valid for the whole run
+// since the fixture token keys are fixed.
+var tokens sync.Map
+
+func recordToken(t testing.TB, collection string, id string) string {
+	if token, ok := tokens.Load(collection + "/" + id); ok {
+		return token.(string)
+	}
+
+	app := newTestApp(t)
+	defer app.Cleanup()
+
+	user, err := app.FindRecordById(collection, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := user.NewAuthToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens.Store(collection+"/"+id, token)
+
+	return token
+}
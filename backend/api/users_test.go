@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestPointsFloorIsProtected(t *testing.T) {
+	token := authToken(t, testUser)
+
+	scenario := tests.ApiScenario{
+		Method:          http.MethodPatch,
+		URL:             "/api/collections/users/records/" + testUser,
+		Body:            strings.NewReader(`{"points_floor":100}`),
+		Headers:         map[string]string{"Authorization": token},
+		ExpectedStatus:  400,
+		ExpectedContent: []string{`"data":{}`},
+		ExpectedEvents:  map[string]int{"*": 0, "OnRecordUpdateRequest": 1},
+		TestAppFactory:  newTestApp,
+	}
+	scenario.Test(t)
+}
+
+func TestPointsOnlyChangeThroughLedger(t *testing.T) {
+	scenario := tests.ApiScenario{
+		Method:          http.MethodPatch,
+		URL:             "/api/collections/users/records/" + testUser,
+		Body:            strings.NewReader(`{"points":1000}`),
+		Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+		ExpectedStatus:  400,
+		ExpectedContent: []string{`"data":{}`},
+		ExpectedEvents:  map[string]int{"*": 0, "OnRecordUpdateRequest": 1},
+		TestAppFactory:  newTestApp,
+		AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			user, err := app.FindRecordById("users", testUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.GetInt("points") != 10 {
+				t.Errorf("Points changed to %d", user.GetInt("points"))
+			}
+		},
+	}
+	scenario.Test(t)
+}
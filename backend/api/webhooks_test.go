@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/webhooks"
+)
+
+func TestWebhookURLIsChecked(t *testing.T) {
+	token := authToken(t, testUser)
+
+	for _, url := range []string{
+		"http://127.0.0.1:8090/api",
+		"http://169.254.169.254/latest/meta-data",
+		"http://192.168.1.1/hook",
+		"gopher://203.0.113.10/hook",
+	} {
+		scenario := tests.ApiScenario{
+			Name:            url,
+			Method:          http.MethodPost,
+			URL:             "/api/collections/webhooks/records",
+			Body:            strings.NewReader(`{"user":"` + testUser + `","url":"` + url + `","secret":"secretsecretsecret","active":true}`),
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"message":"` + webhooks.ErrURL.Error() + `."`},
+			ExpectedEvents:  map[string]int{"*": 0, "OnRecordCreateRequest": 1},
+			TestAppFactory:  newTestApp,
+		}
+		scenario.Test(t)
+	}
+}
+
+func TestIncrementEmitsCompleted(t *testing.T) {
+	scenario := tests.ApiScenario{
+		Method:          http.MethodPost,
+		URL:             "/api/orgtool/entries/" + testEntry + "/progress",
+		Body:            strings.NewReader(`{"delta":2}`),
+		Headers:         map[string]string{"Authorization": authToken(t, testUser)},
+		ExpectedStatus:  200,
+		ExpectedContent: []string{`"completed_by":"` + testUser + `"`},
+		TestAppFactory:  newTestApp,
+		BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			testutil.NewRecord(t, app, webhooks.Collection, "testwebhook0001", map[string]any{
+				"user":   testUser,
+				"url":    "https://203.0.113.10/hook",
+				"secret": "secretsecretsecret",
+				"active": true,
+			})
+		},
+		AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+			n, err := app.CountRecords(
+				webhooks.DeliveriesCollection,
+				dbx.HashExp{"event": webhooks.EventEntryCompleted},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Errorf("Expected the completion to be queued once, got %d", n)
+			}
+		},
+	}
+	scenario.Test(t)
+}
@@ -0,0 +1,246 @@
+package approvals
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/leaderboard"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+const Collection = "approvals"
+
+const (
+	KindEntry      = "entry"
+	KindRedemption = "redemption"
+)
+
+var Kinds = []string{KindEntry, KindRedemption}
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	// Nobody decided in time, handled like a rejection
+	StatusExpired = "expired"
+)
+
+var Statuses = []string{StatusPending, StatusApproved, StatusRejected, StatusExpired}
+
+// Time supervisors have to decide
+const Timeout = 72 * time.Hour
+
+var (
+	ErrNotSupervisor = errors.New("Only the user supervisors can decide on the approval")
+	ErrDecided       = errors.New("The approval was already decided")
+)
+
+// Required reports whether the awards and redemptions of the user wait for a
+// supervisor.
+func Required(user *core.Record) bool {
+	return user.GetBool("requires_approval") && len(user.GetStringSlice("supervisors")) > 0
+}
+
+func IsSupervisor(user *core.Record, supervisorId string) bool {
+	return slices.Contains(user.GetStringSlice("supervisors"), supervisorId)
+}
+
+func newApproval(app core.App, user *core.Record, kind string) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	approval := core.NewRecord(collection)
+	approval.Set("user", user.Id)
+	approval.Set("kind", kind)
+	approval.Set("status", StatusPending)
+	approval.Set("expires_at", time.Now().Add(Timeout))
+
+	return approval, nil
+}
+
+// RequestAward holds the points of a closed entry until a supervisor of user
+// approves them.
+func RequestAward(app core.App, user *core.Record, entry *core.Record, points int) (*core.Record, error) {
+	approval, err := newApproval(app, user, KindEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	approval.Set("entry", entry.Id)
+	approval.Set("points", points)
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		entry.Set("pending_approval", true)
+		if err := txApp.Save(entry); err != nil {
+			return err
+		}
+
+		return txApp.Save(approval)
+	})
+
+	return approval, err
+}
+
+// RequestRedemption holds the redemption of quantity units of the reward until
+// a supervisor of user approves it. Nothing is debited until then.
+func RequestRedemption(app core.App, user *core.Record, reward *core.Record, quantity int) (*core.Record, error) {
+	if quantity <= 0 {
+		return nil, rewards.ErrQuantity
+	}
+
+	approval, err := newApproval(app, user, KindRedemption)
+	if err != nil {
+		return nil, err
+	}
+
+	approval.Set("reward", reward.Id)
+	approval.Set("quantity", quantity)
+	approval.Set("points", quantity*reward.GetInt("unit_cost"))
+
+	return approval, app.Save(approval)
+}
+
+// Approve pays out the award or carries out the redemption of the approval.
+func Approve(app core.App, approvalId string, supervisor *core.Record) (approval *core.Record, err error) {
+	err = app.RunInTransaction(func(txApp core.App) error {
+		var user *core.Record
+		approval, user, err = pending(txApp, approvalId, supervisor)
+		if err != nil {
+			return err
+		}
+
+		switch approval.GetString("kind") {
+		case KindEntry:
+			entry, err := txApp.FindRecordById(entries.Collection, approval.GetString("entry"))
+			if err != nil {
+				return err
+			}
+
+			_, err = ledger.Record(txApp, user, approval.GetInt("points"), ledger.ReasonAward, entry)
+			if err != nil {
+				return err
+			}
+
+			entry.Set("pending_approval", false)
+			if err := txApp.Save(entry); err != nil {
+				return err
+			}
+
+			// The award counts in the user's groups right away
+			if err := leaderboard.RefreshUsers(txApp, []string{user.Id}, time.Now()); err != nil {
+				return err
+			}
+		case KindRedemption:
+			_, _, err := rewards.Redeem(txApp, approval.GetString("reward"), user.Id, approval.GetInt("quantity"))
+			if err != nil {
+				return err
+			}
+		}
+
+		return decide(txApp, approval, StatusApproved, supervisor)
+	})
+
+	return
+}
+
+// Reject drops the approval: the award is never paid, the redemption never
+// happens.
+func Reject(app core.App, approvalId string, supervisor *core.Record) (approval *core.Record, err error) {
+	err = app.RunInTransaction(func(txApp core.App) error {
+		approval, _, err = pending(txApp, approvalId, supervisor)
+		if err != nil {
+			return err
+		}
+
+		if err := clearEntry(txApp, approval); err != nil {
+			return err
+		}
+
+		return decide(txApp, approval, StatusRejected, supervisor)
+	})
+
+	return
+}
+
+// Expire marks the approvals nobody decided on before now as expired.
+func Expire(app core.App, now time.Time) (int, error) {
+	records, err := app.FindAllRecords(
+		Collection,
+		dbx.HashExp{"status": StatusPending},
+		dbx.NewExp("expires_at <= {:now}", dbx.Params{"now": now.UTC().Format(types.DefaultDateLayout)}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, approval := range records {
+		err := app.RunInTransaction(func(txApp core.App) error {
+			if err := clearEntry(txApp, approval); err != nil {
+				return err
+			}
+
+			return decide(txApp, approval, StatusExpired, nil)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(records), nil
+}
+
+func pending(app core.App, approvalId string, supervisor *core.Record) (*core.Record, *core.Record, error) {
+	approval, err := app.FindRecordById(Collection, approvalId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := app.FindRecordById("users", approval.GetString("user"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !IsSupervisor(user, supervisor.Id) {
+		return nil, nil, ErrNotSupervisor
+	}
+
+	if approval.GetString("status") != StatusPending {
+		return nil, nil, ErrDecided
+	}
+
+	return approval, user, nil
+}
+
+func decide(app core.App, approval *core.Record, status string, supervisor *core.Record) error {
+	approval.Set("status", status)
+	approval.Set("decided_at", types.NowDateTime())
+	if supervisor != nil {
+		approval.Set("decided_by", supervisor.Id)
+	}
+
+	return app.Save(approval)
+}
+
+func clearEntry(app core.App, approval *core.Record) error {
+	if approval.GetString("kind") != KindEntry {
+		return nil
+	}
+
+	entry, err := app.FindRecordById(entries.Collection, approval.GetString("entry"))
+	if err != nil {
+		return nil
+	}
+
+	entry.Set("pending_approval", false)
+
+	return app.Save(entry)
+}
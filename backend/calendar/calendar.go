@@ -0,0 +1,223 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/period"
+)
+
+const TokensCollection = "calendar_tokens"
+
+// RFC 5545 content lines are folded after 75 octets
+const lineLength = 75
+
+const dateTimeLayout = "20060102T150405Z"
+
+const (
+	// Entries as to-dos spanning their period
+	KindTodo = "todo"
+	// Entries as events at their deadline, for apps without to-dos
+	KindEvent = "event"
+)
+
+var Kinds = []string{KindTodo, KindEvent}
+
+// Todo is an open entry as it is shown in the calendar: due when its period
+// closes.
+type Todo struct {
+	Id       string
+	Summary  string
+	Start    time.Time
+	Due      time.Time
+	Progress int
+	Goal     int
+	Updated  time.Time
+}
+
+// Todos returns the open entries of the user activities.
+func Todos(app core.App, user *core.Record) ([]Todo, error) {
+	clock := period.UserClock(user)
+
+	activities, err := app.FindRecordsByFilter(
+		"activities",
+		"user = {:user}",
+		"name",
+		0,
+		0,
+		dbx.Params{"user": user.Id},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []Todo
+	for _, activity := range activities {
+		records, err := app.FindRecordsByFilter(
+			entries.Collection,
+			"activity = {:activity} && closed = false",
+			"period_start,created",
+			0,
+			0,
+			dbx.Params{"activity": activity.Id},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range records {
+			start, end, err := entries.Bounds(clock, activity, entry)
+			if err != nil {
+				return nil, err
+			}
+
+			todos = append(todos, Todo{
+				Id:       entry.Id,
+				Summary:  activity.GetString("name"),
+				Start:    start,
+				Due:      end,
+				Progress: entry.GetInt("progress"),
+				Goal:     entry.GetInt("goal"),
+				Updated:  entry.GetDateTime("updated").Time(),
+			})
+		}
+	}
+
+	return todos, nil
+}
+
+// Write renders the todos as an iCalendar feed of the given kind.
+func Write(w io.Writer, name string, kind string, todos []Todo, now time.Time) error {
+	e := &encoder{w: w}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", "-//OrganizationTool//Calendar//EN")
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	e.line("X-WR-CALNAME", escape(name))
+
+	for _, todo := range todos {
+		if kind == KindEvent {
+			e.event(todo, now)
+		} else {
+			e.todo(todo, now)
+		}
+	}
+
+	e.line("END", "VCALENDAR")
+
+	return e.err
+}
+
+// =============================================================================
+// HELPERS
+//
+
+type encoder struct {
+	w   io.Writer
+	err error
+}
+
+func (e *encoder) todo(todo Todo, now time.Time) {
+	e.line("BEGIN", "VTODO")
+	e.line("UID", todo.Id+"@orgtool")
+	e.line("DTSTAMP", now.UTC().Format(dateTimeLayout))
+	e.line("DTSTART", todo.Start.UTC().Format(dateTimeLayout))
+	e.line("DUE", todo.Due.UTC().Format(dateTimeLayout))
+	e.line("SUMMARY", escape(todo.Summary))
+	e.line("DESCRIPTION", escape(todo.description()))
+
+	if todo.done() {
+		e.line("STATUS", "COMPLETED")
+		e.line("PERCENT-COMPLETE", "100")
+	} else {
+		e.line("STATUS", "NEEDS-ACTION")
+		if todo.Goal > 0 {
+			e.line("PERCENT-COMPLETE", fmt.Sprint(max(todo.Progress, 0)*100/todo.Goal))
+		}
+	}
+
+	if !todo.Updated.IsZero() {
+		e.line("LAST-MODIFIED", todo.Updated.UTC().Format(dateTimeLayout))
+	}
+	e.line("END", "VTODO")
+}
+
+// event renders the deadline alone, without DTEND it takes no time
+func (e *encoder) event(todo Todo, now time.Time) {
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", todo.Id+"@orgtool")
+	e.line("DTSTAMP", now.UTC().Format(dateTimeLayout))
+	e.line("DTSTART", todo.Due.UTC().Format(dateTimeLayout))
+	e.line("SUMMARY", escape(todo.Summary))
+	e.line("DESCRIPTION", escape(todo.description()))
+	e.line("TRANSP", "TRANSPARENT")
+
+	if !todo.Updated.IsZero() {
+		e.line("LAST-MODIFIED", todo.Updated.UTC().Format(dateTimeLayout))
+	}
+	e.line("END", "VEVENT")
+}
+
+func (t Todo) done() bool {
+	return t.Goal > 0 && t.Progress >= t.Goal
+}
+
+func (t Todo) description() string {
+	return fmt.Sprintf("Progress %d/%d", t.Progress, t.Goal)
+}
+
+func (e *encoder) line(name string, value string) {
+	if e.err != nil {
+		return
+	}
+
+	_, e.err = io.WriteString(e.w, fold(name+":"+value))
+}
+
+// fold splits the content line in lines of at most 75 octets, continuation
+// lines starting with a space, without breaking UTF-8 sequences.
+func fold(line string) string {
+	var b strings.Builder
+
+	limit := lineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+
+		// The leading space counts towards the length
+		limit = lineLength - 1
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escape(text string) string {
+	return escaper.Replace(text)
+}
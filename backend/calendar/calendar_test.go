@@ -0,0 +1,258 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// component is a parsed iCalendar component with its properties unescaped.
+type component struct {
+	name       string
+	props      map[string][]string
+	components []*component
+}
+
+func (c *component) prop(name string) string {
+	if len(c.props[name]) == 0 {
+		return ""
+	}
+
+	return c.props[name][0]
+}
+
+// parse is a strict reader of the RFC 5545 subset the feed uses: CRLF content
+// lines of at most 75 octets, folded with a leading space, nested BEGIN/END
+// blocks and escaped text values.
+func parse(data []byte) (*component, error) {
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		return nil, fmt.Errorf("The feed doesn't end with CRLF")
+	}
+
+	var lines []string
+	for i, raw := range strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n") {
+		if strings.ContainsAny(raw, "\r\n") {
+			return nil, fmt.Errorf("Line %d has a bare line break", i+1)
+		}
+		if len(raw) > lineLength {
+			return nil, fmt.Errorf("Line %d is %d octets long", i+1, len(raw))
+		}
+		if !utf8.ValidString(raw) {
+			return nil, fmt.Errorf("Line %d breaks a UTF-8 sequence", i+1)
+		}
+
+		if strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t") {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("The feed starts with a continuation line")
+			}
+			lines[len(lines)-1] += raw[1:]
+			continue
+		}
+
+		lines = append(lines, raw)
+	}
+
+	var stack []*component
+	var root *component
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("Malformed content line %q", line)
+		}
+		name, _, _ = strings.Cut(name, ";")
+
+		switch name {
+		case "BEGIN":
+			c := &component{name: value, props: map[string][]string{}}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.components = append(parent.components, c)
+			} else if root != nil {
+				return nil, fmt.Errorf("More than one top level component")
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != value {
+				return nil, fmt.Errorf("Unbalanced END:%s", value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("Property %s outside of a component", name)
+			}
+			text, err := unescape(value)
+			if err != nil {
+				return nil, err
+			}
+			c := stack[len(stack)-1]
+			c.props[name] = append(c.props[name], text)
+		}
+	}
+
+	if len(stack) > 0 || root == nil {
+		return nil, fmt.Errorf("Unterminated components")
+	}
+
+	return root, nil
+}
+
+func unescape(value string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\':
+			i++
+			if i == len(value) {
+				return "", fmt.Errorf("Dangling escape in %q", value)
+			}
+			switch value[i] {
+			case '\\', ';', ',':
+				b.WriteByte(value[i])
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				return "", fmt.Errorf("Invalid escape in %q", value)
+			}
+		case value[i] == ';' || value[i] == ',':
+			return "", fmt.Errorf("Unescaped separator in %q", value)
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// validate checks the properties RFC 5545 requires of the feed components.
+func validate(t *testing.T, cal *component, child string) {
+	t.Helper()
+
+	if cal.name != "VCALENDAR" || cal.prop("VERSION") != "2.0" || cal.prop("PRODID") == "" {
+		t.Fatalf("Invalid calendar %s: %v", cal.name, cal.props)
+	}
+
+	for _, c := range cal.components {
+		if c.name != child {
+			t.Errorf("Unexpected %s component", c.name)
+		}
+
+		for _, name := range []string{"UID", "DTSTAMP", "DTSTART"} {
+			if len(c.props[name]) != 1 {
+				t.Errorf("%s has %d %s properties", c.name, len(c.props[name]), name)
+			}
+		}
+
+		for _, name := range []string{"DTSTAMP", "DTSTART", "DUE", "LAST-MODIFIED"} {
+			if value := c.prop(name); value != "" {
+				if _, err := time.Parse(dateTimeLayout, value); err != nil {
+					t.Errorf("%s %s is not a UTC date-time: %v", c.name, name, err)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteParses(t *testing.T) {
+	now := time.Date(2025, time.March, 30, 10, 0, 0, 0, time.UTC)
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos := []Todo{
+		{
+			Id:       "entry000000001",
+			Summary:  "Run",
+			Start:    time.Date(2025, time.March, 29, 6, 0, 0, 0, rome),
+			Due:      time.Date(2025, time.March, 30, 6, 0, 0, 0, rome),
+			Progress: 1,
+			Goal:     2,
+			Updated:  now,
+		},
+		{
+			Id:       "entry000000002",
+			Summary:  strings.Repeat("Lettura, scrittura; e \\ caffè ☕\n", 6),
+			Start:    time.Date(2025, time.March, 24, 6, 0, 0, 0, rome),
+			Due:      time.Date(2025, time.March, 31, 6, 0, 0, 0, rome),
+			Progress: 3,
+			Goal:     3,
+		},
+	}
+
+	for kind, child := range map[string]string{KindTodo: "VTODO", KindEvent: "VEVENT"} {
+		t.Run(kind, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, "Home, work; and more", kind, todos, now); err != nil {
+				t.Fatal(err)
+			}
+
+			cal, err := parse(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			validate(t, cal, child)
+
+			if name := cal.prop("X-WR-CALNAME"); name != "Home, work; and more" {
+				t.Errorf("Calendar name is %q", name)
+			}
+
+			if len(cal.components) != len(todos) {
+				t.Fatalf("Expected %d components, got %d", len(todos), len(cal.components))
+			}
+
+			for i, todo := range todos {
+				c := cal.components[i]
+				if c.prop("UID") != todo.Id+"@orgtool" {
+					t.Errorf("UID is %q", c.prop("UID"))
+				}
+				if c.prop("SUMMARY") != todo.Summary {
+					t.Errorf("Summary %q doesn't round trip", c.prop("SUMMARY"))
+				}
+
+				// Events sit on the deadline, to-dos span the period
+				due := c.prop("DUE")
+				if kind == KindEvent {
+					due = c.prop("DTSTART")
+				}
+				if due != todo.Due.UTC().Format(dateTimeLayout) {
+					t.Errorf("Deadline is %s, expected %s", due, todo.Due.UTC())
+				}
+			}
+
+			if kind == KindTodo {
+				if s := cal.components[0].prop("STATUS"); s != "NEEDS-ACTION" || cal.components[0].prop("PERCENT-COMPLETE") != "50" {
+					t.Errorf("Open to-do is %s at %s%%", s, cal.components[0].prop("PERCENT-COMPLETE"))
+				}
+				if s := cal.components[1].prop("STATUS"); s != "COMPLETED" {
+					t.Errorf("Met to-do is %s", s)
+				}
+			}
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	for _, line := range []string{
+		"SUMMARY:short",
+		"SUMMARY:" + strings.Repeat("a", 200),
+		"SUMMARY:" + strings.Repeat("è", 100),
+		"SUMMARY:" + strings.Repeat("☕x", 60),
+	} {
+		folded := fold(line)
+
+		for _, part := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+			if len(part) > lineLength || !utf8.ValidString(part) {
+				t.Errorf("Folded line %q is invalid", part)
+			}
+		}
+
+		if unfolded := strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""); unfolded != line {
+			t.Errorf("Unfolding gives %q, expected %q", unfolded, line)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package challenges
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/groups"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+const (
+	Collection             = "challenges"
+	ParticipantsCollection = "challenge_participants"
+)
+
+const (
+	// The participants that completed the most periods share the pot
+	WinMostCompleted = "most_completed"
+	// The participants that didn't miss a single period share the pot
+	WinNoneMissed = "none_missed"
+)
+
+var WinRules = []string{WinMostCompleted, WinNoneMissed}
+
+const (
+	StatusOpen     = "open"
+	StatusFinished = "finished"
+)
+
+var Statuses = []string{StatusOpen, StatusFinished}
+
+var (
+	ErrStarted         = errors.New("The challenge already started")
+	ErrNotMember       = errors.New("Only the group members can join the challenge")
+	ErrActivity        = errors.New("The challenge activity must be one of yours and follow the challenge template")
+	ErrTemplate        = errors.New("The challenge template must be an activity of yours or of the group, of the challenge type")
+	ErrNotEnoughPoints = errors.New("Not enough points to join the challenge")
+)
+
+// Validate checks the challenge settings before it is saved.
+func Validate(challenge *core.Record) error {
+	start := challenge.GetDateTime("start").Time()
+	end := challenge.GetDateTime("end").Time()
+	if !end.After(start) {
+		return fmt.Errorf("The challenge must end after it starts")
+	}
+
+	if !slices.Contains(WinRules, challenge.GetString("win_rule")) {
+		return fmt.Errorf("Not known win rule '%s'", challenge.GetString("win_rule"))
+	}
+
+	return nil
+}
+
+// CheckTemplate checks the owner of the challenge can follow its template.
+func CheckTemplate(app core.App, challenge *core.Record) error {
+	id := challenge.GetString("activity")
+	if id == "" {
+		return nil
+	}
+
+	template, err := app.FindRecordById("activities", id)
+	if err != nil || template.GetString("type") != challenge.GetString("type") {
+		return ErrTemplate
+	}
+
+	if template.GetString("user") == challenge.GetString("owner") {
+		return nil
+	}
+
+	if group := template.GetString("group"); group == "" || group != challenge.GetString("group") {
+		return ErrTemplate
+	}
+
+	return nil
+}
+
+// Follows reports whether the activity counts towards the challenge: the
+// template itself or an activity created from it. Challenges without a
+// template follow any activity of their type.
+func Follows(challenge *core.Record, activity *core.Record) bool {
+	if activity.GetString("type") != challenge.GetString("type") {
+		return false
+	}
+
+	template := challenge.GetString("activity")
+
+	return template == "" || activity.Id == template || activity.GetString("template") == template
+}
+
+// Join checks the participant can take part in the challenge and puts their
+// stake in the pot. The participant is saved by the caller.
+func Join(app core.App, participant *core.Record, now time.Time) error {
+	challenge, err := app.FindRecordById(Collection, participant.GetString("challenge"))
+	if err != nil {
+		return err
+	}
+
+	if challenge.GetString("status") != StatusOpen || !now.Before(challenge.GetDateTime("start").Time()) {
+		return ErrStarted
+	}
+
+	userId := participant.GetString("user")
+	if group := challenge.GetString("group"); group != "" && !groups.IsMember(app, group, userId) {
+		return ErrNotMember
+	}
+
+	activity, err := app.FindRecordById("activities", participant.GetString("activity"))
+	if err != nil || activity.GetString("user") != userId || !Follows(challenge, activity) {
+		return ErrActivity
+	}
+
+	stake := challenge.GetInt("stake")
+	if stake == 0 {
+		return nil
+	}
+
+	user, err := app.FindRecordById("users", userId)
+	if err != nil {
+		return err
+	}
+
+	if rewards.Available(user) < stake {
+		return ErrNotEnoughPoints
+	}
+
+	if _, err := ledger.Record(app, user, -stake, ledger.ReasonChallenge, challenge); err != nil {
+		return err
+	}
+
+	challenge.Set("pot", challenge.GetInt("pot")+stake)
+
+	return app.Save(challenge)
+}
+
+// Leave gives the stake back to a participant leaving the challenge before it
+// starts. The participant is deleted by the caller.
+func Leave(app core.App, participant *core.Record, now time.Time) error {
+	challenge, err := app.FindRecordById(Collection, participant.GetString("challenge"))
+	if err != nil {
+		return err
+	}
+
+	if challenge.GetString("status") != StatusOpen || !now.Before(challenge.GetDateTime("start").Time()) {
+		return ErrStarted
+	}
+
+	stake := challenge.GetInt("stake")
+	if stake == 0 {
+		return nil
+	}
+
+	user, err := app.FindRecordById("users", participant.GetString("user"))
+	if err != nil {
+		return err
+	}
+
+	if _, err := ledger.Record(app, user, stake, ledger.ReasonChallenge, challenge); err != nil {
+		return err
+	}
+
+	challenge.Set("pot", challenge.GetInt("pot")-stake)
+
+	return app.Save(challenge)
+}
+
+// Track counts the closed entry towards the challenges following its
+// activity, when its period is within them.
+func Track(app core.App, clock period.Clock, activity *core.Record, entry *core.Record) error {
+	// Periods missed while the server was down don't count against the user
+	if entry.GetBool("missed") {
+		return nil
+	}
+
+	participants, err := app.FindAllRecords(
+		ParticipantsCollection,
+		dbx.HashExp{"activity": activity.Id},
+	)
+	if err != nil {
+		return err
+	}
+
+	start, end, err := entries.Bounds(clock, activity, entry)
+	if err != nil {
+		return err
+	}
+
+	for _, participant := range participants {
+		challenge, err := app.FindRecordById(Collection, participant.GetString("challenge"))
+		if err != nil {
+			return err
+		}
+
+		if challenge.GetString("status") != StatusOpen || !Follows(challenge, activity) ||
+			start.Before(challenge.GetDateTime("start").Time()) ||
+			end.After(challenge.GetDateTime("end").Time()) {
+			continue
+		}
+
+		if entry.GetInt("progress") >= entry.GetInt("goal") {
+			participant.Set("completed", participant.GetInt("completed")+1)
+		} else {
+			participant.Set("missed", participant.GetInt("missed")+1)
+		}
+
+		if err := app.Save(participant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FinishEnded settles the open challenges that ended before now.
+func FinishEnded(app core.App, now time.Time) error {
+	ended, err := app.FindAllRecords(
+		Collection,
+		dbx.HashExp{"status": StatusOpen},
+		dbx.NewExp("end <= {:now}", dbx.Params{"now": now.UTC().Format(types.DefaultDateLayout)}),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, challenge := range ended {
+		err := app.RunInTransaction(func(txApp core.App) error {
+			return Finish(txApp, challenge)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Finish picks the winners of the challenge and splits the pot between them.
+// Without winners the stakes go back to the participants.
+func Finish(app core.App, challenge *core.Record) error {
+	participants, err := app.FindRecordsByFilter(
+		ParticipantsCollection,
+		"challenge = {:challenge}",
+		"-completed,created",
+		0,
+		0,
+		dbx.Params{"challenge": challenge.Id},
+	)
+	if err != nil {
+		return err
+	}
+
+	winners := Winners(challenge.GetString("win_rule"), participants)
+
+	pot := challenge.GetInt("pot")
+	payouts := make([]int, len(participants))
+	if len(winners) > 0 {
+		for i, index := range winners {
+			payouts[index] = pot / len(winners)
+			// The first winner gets what can't be split
+			if i == 0 {
+				payouts[index] += pot % len(winners)
+			}
+		}
+	} else if pot > 0 {
+		for i := range participants {
+			payouts[i] = challenge.GetInt("stake")
+		}
+	}
+
+	for i, participant := range participants {
+		participant.Set("winner", slices.Contains(winners, i))
+		participant.Set("payout", payouts[i])
+		if err := app.Save(participant); err != nil {
+			return err
+		}
+
+		if payouts[i] == 0 {
+			continue
+		}
+
+		user, err := app.FindRecordById("users", participant.GetString("user"))
+		if err != nil {
+			return err
+		}
+
+		if _, err := ledger.Record(app, user, payouts[i], ledger.ReasonChallenge, challenge); err != nil {
+			return err
+		}
+	}
+
+	challenge.Set("pot", 0)
+	challenge.Set("status", StatusFinished)
+
+	return app.Save(challenge)
+}
+
+// Winners returns the indexes of the winning participants.
+func Winners(rule string, participants []*core.Record) []int {
+	best := 0
+	for _, participant := range participants {
+		best = max(best, participant.GetInt("completed"))
+	}
+
+	var winners []int
+	for i, participant := range participants {
+		completed := participant.GetInt("completed")
+		if completed == 0 {
+			continue
+		}
+
+		switch rule {
+		case WinMostCompleted:
+			if completed == best {
+				winners = append(winners, i)
+			}
+		case WinNoneMissed:
+			if participant.GetInt("missed") == 0 {
+				winners = append(winners, i)
+			}
+		}
+	}
+
+	return winners
+}
@@ -8,8 +8,6 @@ import (
 )
 
 func calculatePointsV2Cron(app core.App) func() {
-	app.Cron().Remove("calculatePoints")
-
 	tables := []string{
 		"daily_entries",
 		"weekly_entries",
@@ -58,8 +56,6 @@ func calculatePointsV2Cron(app core.App) func() {
 }
 
 func createNewDailyEntriesV2Cron(app core.App) func() {
-	app.Cron().Remove("createNewDailyEntries")
-
 	return func() {
 		app.RunInTransaction(func(txApp core.App) error {
 			activities, err := txApp.FindAllRecords("activities", dbx.NewExp("type = 'daily'"))
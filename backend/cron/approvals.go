@@ -0,0 +1,23 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/approvals"
+)
+
+func expireApprovalsCron(app core.App) func() {
+	return func() {
+		expired, err := approvals.Expire(app, time.Now())
+		if err != nil {
+			app.Logger().Error("Unable to expire approvals", "error", err)
+			return
+		}
+
+		if expired > 0 {
+			app.Logger().Info("Expired pending approvals", "count", expired)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/challenges"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+func newParticipant(t testing.TB, app core.App, challenge *core.Record, activity *core.Record) *core.Record {
+	return testutil.NewRecord(t, app, challenges.ParticipantsCollection, "", map[string]any{
+		"challenge": challenge.Id,
+		"user":      activity.GetString("user"),
+		"activity":  activity.Id,
+	})
+}
+
+func TestTrackFollowsTemplate(t *testing.T) {
+	app := newTestApp(t)
+	owner := testutil.NewUser(t, app, "", "owner@example.com", 0)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	other := testutil.NewUser(t, app, "", "other@example.com", 0)
+
+	template := newActivity(t, app, owner, nil)
+	linked := newActivity(t, app, user, map[string]any{"template": template.Id})
+	unlinked := newActivity(t, app, other, nil)
+
+	challenge := testutil.NewRecord(t, app, challenges.Collection, "", map[string]any{
+		"name":     "Run every day",
+		"owner":    owner.Id,
+		"type":     "daily",
+		"activity": template.Id,
+		"start":    openEntry(t, app, template).GetDateTime("period_start"),
+		"end":      time.Now().Add(days(30)),
+		"win_rule": challenges.WinNoneMissed,
+		"status":   challenges.StatusOpen,
+	})
+
+	participants := []*core.Record{
+		newParticipant(t, app, challenge, template),
+		newParticipant(t, app, challenge, linked),
+		// Its template was changed after joining
+		newParticipant(t, app, challenge, unlinked),
+	}
+
+	for _, activity := range []*core.Record{template, linked, unlinked} {
+		complete(t, app, openEntry(t, app, activity))
+	}
+
+	// The server is down for the next periods
+	if err := closePeriods(app, time.Now().Add(days(4))); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct{ completed, missed int }{{1, 0}, {1, 0}, {0, 0}}
+	for i, participant := range participants {
+		participant = testutil.Reload(t, app, participant)
+		if participant.GetInt("completed") != expected[i].completed || participant.GetInt("missed") != expected[i].missed {
+			t.Errorf(
+				"Participant %d completed %d and missed %d periods, expected %d and %d",
+				i, participant.GetInt("completed"), participant.GetInt("missed"),
+				expected[i].completed, expected[i].missed,
+			)
+		}
+		participants[i] = participant
+	}
+
+	if winners := challenges.Winners(challenges.WinNoneMissed, participants); len(winners) != 2 {
+		t.Errorf("Expected the downtime to keep 2 winners, got %v", winners)
+	}
+}
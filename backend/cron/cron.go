@@ -2,74 +2,95 @@ package cron
 
 import (
 	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/migrations"
 )
 
-type MigrationCron struct {
-	Name    string
-	CronTab string
-	Func    func()
+type Job struct {
+	Name    string                `json:"name"`
+	CronTab string                `json:"cronTab"`
+	New     func(core.App) func() `json:"-"`
 }
 
-func NewMigrationCron(
-	name string,
-	cronTab string,
-	fun func(),
-) MigrationCron {
-	return MigrationCron{
+func NewJob(name string, cronTab string, fun func(core.App) func()) Job {
+	return Job{
 		Name:    name,
 		CronTab: cronTab,
-		Func:    fun,
+		New:     fun,
 	}
 }
 
-var migrationCrons map[int][]MigrationCron
-
-func InitMigrationsCron(app core.App) {
-	migrationCrons = make(map[int][]MigrationCron)
-	migrationCrons[7] = []MigrationCron{
-		NewMigrationCron("calculatePoints", "0 6 * * *", calculatePointsCron(app)),
-		NewMigrationCron("createNewDailyEntries", "1 6 * * *", createNewDailyEntriesCron(app)),
-		NewMigrationCron("updateRedeemedRewards", "0 6 * * *", updateRedeemedRewardsCron(app)),
-	}
-	migrationCrons[8] = []MigrationCron{
-		NewMigrationCron("calculatePoints", "0 6 * * *", calculatePointsV2Cron(app)),
-		NewMigrationCron("createNewDailyEntries", "1 6 * * *", createNewDailyEntriesV2Cron(app)),
-		NewMigrationCron("createNewWeeklyEntries", "1 6 * * 1", createNewWeeklyEntriesCron(app)),
-		NewMigrationCron("createNewMonthlyEntries", "1 6 1 * *", createNewMonthlyEntriesCron(app)),
-		NewMigrationCron("createNewYearlyEntries", "1 6 1 1 *", createNewYearlyEntriesCron(app)),
-	}
-
-	applyMigrationCron(app)
+// Job sets keyed by the migration that introduced them. Each set replaces the
+// previous one entirely: only the one of the latest applied migration runs.
+var registry = map[string][]Job{
+	"1751529958_create_database.go": {
+		NewJob("calculatePoints", "0 6 * * *", calculatePointsCron),
+		NewJob("createNewDailyEntries", "1 6 * * *", createNewDailyEntriesCron),
+		NewJob("updateRedeemedRewards", "0 6 * * *", updateRedeemedRewardsCron),
+	},
+	"1751618410_activities.go": {
+		NewJob("calculatePoints", "0 6 * * *", calculatePointsV2Cron),
+		NewJob("createNewDailyEntries", "1 6 * * *", createNewDailyEntriesV2Cron),
+		NewJob("createNewWeeklyEntries", "1 6 * * 1", createNewWeeklyEntriesCron),
+		NewJob("createNewMonthlyEntries", "1 6 1 * *", createNewMonthlyEntriesCron),
+		NewJob("createNewYearlyEntries", "1 6 1 1 *", createNewYearlyEntriesCron),
+	},
+	"1751790000_users_timezone.go": {
+		NewJob("closePeriods", "* * * * *", closePeriodsCron),
+		NewJob("updateRedeemedRewards", "0 6 * * *", updateRedeemedRewardsCron),
+	},
+	"1751800000_point_transactions.go": {
+		NewJob("closePeriods", "* * * * *", closePeriodsCron),
+		NewJob("updateRedeemedRewards", "0 6 * * *", updateRedeemedRewardsCron),
+		NewJob("reconcilePoints", "30 5 * * *", reconcilePointsCron),
+	},
+	"1751880000_rewards_reset.go": {
+		NewJob("closePeriods", "* * * * *", closePeriodsCron),
+		NewJob("resetRewards", "* * * * *", resetRewardsCron),
+		NewJob("reconcilePoints", "30 5 * * *", reconcilePointsCron),
+	},
+	"1751940000_approvals.go": {
+		NewJob("closePeriods", "* * * * *", closePeriodsCron),
+		NewJob("resetRewards", "* * * * *", resetRewardsCron),
+		NewJob("reconcilePoints", "30 5 * * *", reconcilePointsCron),
+		NewJob("expireApprovals", "*/5 * * * *", expireApprovalsCron),
+	},
+	"1751990000_webhooks.go": {
+		NewJob("closePeriods", "* * * * *", closePeriodsCron),
+		NewJob("resetRewards", "* * * * *", resetRewardsCron),
+		NewJob("reconcilePoints", "30 5 * * *", reconcilePointsCron),
+		NewJob("expireApprovals", "*/5 * * * *", expireApprovalsCron),
+		NewJob("deliverWebhooks", "* * * * *", deliverWebhooksCron),
+	},
 }
 
-func applyMigrationCron(app core.App) {
-	ids, err := getMigrationIDs(app)
+// Active returns the job set of the latest applied migration that has one.
+func Active(app core.App) (version string, jobs []Job, err error) {
+	files, err := migrations.Applied(app)
 	if err != nil {
-		app.Logger().Warn("It was not possible to retrieve migrations IDs")
+		return "", nil, err
 	}
 
-	for _, id := range ids {
-		if entry, ok := migrationCrons[id]; ok {
-			for _, cron := range entry {
-				app.Cron().MustAdd(cron.Name, cron.CronTab, cron.Func)
-			}
+	for _, file := range files {
+		if set, ok := registry[file]; ok {
+			version = file
+			jobs = set
 		}
 	}
+
+	return version, jobs, nil
 }
 
-func getMigrationIDs(app core.App) (id []int, err error) {
-	migrations := []struct {
-		Id      int `db:"id"`
-		Applied int `db:"applied"`
-	}{}
-	err = app.DB().
-		NewQuery("SELECT (ROW_NUMBER() OVER()) AS id, applied FROM _migrations ORDER BY applied ASC").
-		All(&migrations)
+func InitMigrationsCron(app core.App) {
+	version, jobs, err := Active(app)
+	if err != nil {
+		app.Logger().Warn("It was not possible to retrieve the applied migrations", "error", err)
+		return
+	}
 
-	id = make([]int, 0)
-	for _, m := range migrations {
-		id = append(id, m.Id)
+	for _, job := range jobs {
+		app.Cron().MustAdd(job.Name, job.CronTab, job.New(app))
 	}
 
-	return
+	app.Logger().Debug("Registered migration crons", "version", version, "jobs", len(jobs))
 }
@@ -0,0 +1,130 @@
+package cron
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	pbcron "github.com/pocketbase/pocketbase/tools/cron"
+)
+
+func jobNames(jobs []Job) []string {
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		names[i] = job.Name
+	}
+
+	return names
+}
+
+func TestRegistryKeys(t *testing.T) {
+	for file, jobs := range registry {
+		if _, err := os.Stat("../migrations/" + file); err != nil {
+			t.Errorf("%s doesn't name a migration: %v", file, err)
+		}
+
+		names := jobNames(jobs)
+		slices.Sort(names)
+		if len(slices.Compact(names)) != len(jobs) {
+			t.Errorf("%s registers a job name twice", file)
+		}
+	}
+}
+
+func TestActivePicksLatestSet(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	version, jobs, err := Active(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != "1751990000_webhooks.go" {
+		t.Fatalf("Active version is %q", version)
+	}
+
+	expected := []string{"closePeriods", "resetRewards", "reconcilePoints", "expireApprovals", "deliverWebhooks"}
+	if names := jobNames(jobs); !slices.Equal(names, expected) {
+		t.Errorf("Active jobs are %v, expected %v", names, expected)
+	}
+
+	// System migrations applied later don't have a set and change nothing
+	_, err = app.DB().Insert(core.DefaultMigrationsTable, dbx.Params{
+		"file":    "1799999999_system_update.go",
+		"applied": 1<<62 - 1,
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version, _, err := Active(app); err != nil || version != "1751990000_webhooks.go" {
+		t.Errorf("Active version after a system migration is %q, %v", version, err)
+	}
+}
+
+func TestActiveAfterRevert(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	reverted, err := core.NewMigrationsRunner(app, core.AppMigrations).Down(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(reverted, []string{
+		"1752010000_challenge_templates.go",
+		"1752000000_redemptions_saved.go",
+		"1751990000_webhooks.go",
+	}) {
+		t.Fatalf("Reverted %v", reverted)
+	}
+
+	version, jobs, err := Active(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != "1751940000_approvals.go" || slices.Contains(jobNames(jobs), "deliverWebhooks") {
+		t.Errorf("Active version after the revert is %q with %v", version, jobNames(jobs))
+	}
+}
+
+func TestInitMigrationsCron(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	before := len(app.Cron().Jobs())
+
+	InitMigrationsCron(app)
+
+	_, jobs, err := Active(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if added := len(app.Cron().Jobs()) - before; added != len(jobs) {
+		t.Fatalf("Registered %d jobs, expected %d", added, len(jobs))
+	}
+
+	for _, job := range jobs {
+		found := slices.ContainsFunc(app.Cron().Jobs(), func(j *pbcron.Job) bool {
+			return j.Id() == job.Name && j.Expression() == job.CronTab
+		})
+		if !found {
+			t.Errorf("Job %s is not registered", job.Name)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package cron
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/ledger"
+)
+
+func reconcilePointsCron(app core.App) func() {
+	return func() {
+		users, err := app.FindAllRecords("users")
+		if err != nil {
+			app.Logger().Error("Unable to reconcile points", "error", err)
+			return
+		}
+
+		for _, user := range users {
+			drift, err := ledger.Reconcile(app, user)
+			if err != nil {
+				app.Logger().Error("Unable to reconcile points", "user", user.Id, "error", err)
+				continue
+			}
+
+			if drift != 0 {
+				app.Logger().Warn("Points drifted from the ledger", "user", user.Id, "drift", drift)
+			}
+		}
+	}
+}
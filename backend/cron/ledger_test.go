@@ -0,0 +1,32 @@
+package cron
+
+import (
+	"testing"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/ledger"
+)
+
+func TestReconcileResetsDrift(t *testing.T) {
+	app := newTestApp(t)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	if _, err := ledger.Record(app, user, 12, ledger.ReasonAward, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Written around the ledger
+	user.Set("points", 40)
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcilePointsCron(app)()
+
+	if points := testutil.Reload(t, app, user).GetInt("points"); points != 12 {
+		t.Errorf("Expected the 12 points of the ledger, got %d", points)
+	}
+
+	if n := countTransactions(t, app, user, ledger.ReasonAdjustment); n != 0 {
+		t.Errorf("The drift was recorded as %d adjustments", n)
+	}
+}
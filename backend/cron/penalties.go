@@ -0,0 +1,66 @@
+package cron
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+// penalize deducts the activity penalty for an unmet entry, once the monthly
+// grace allowance is used up and without going below the user's floor.
+func penalize(
+	txApp core.App,
+	clock period.Clock,
+	user *core.Record,
+	activity *core.Record,
+	entry *core.Record,
+) error {
+	penalty := activity.GetInt("penalty")
+	if penalty <= 0 {
+		return nil
+	}
+
+	start, _, err := entries.Bounds(clock, activity, entry)
+	if err != nil {
+		return err
+	}
+
+	monthStart, monthEnd, err := clock.Bounds(period.Monthly, start)
+	if err != nil {
+		return err
+	}
+
+	// Misses of the month so far, this one included
+	misses, err := txApp.CountRecords(
+		entries.Collection,
+		dbx.HashExp{"activity": activity.Id, "closed": true, "missed": false},
+		dbx.NewExp("progress < goal"),
+		dbx.NewExp("period_start >= {:from} AND period_start < {:to}", dbx.Params{
+			"from": monthStart.UTC().Format(types.DefaultDateLayout),
+			"to":   monthEnd.UTC().Format(types.DefaultDateLayout),
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	if int(misses) <= activity.GetInt("penalty_grace") {
+		return nil
+	}
+
+	// Neither points saved in rewards nor those under the floor can be taken
+	available := max(min(rewards.Available(user), user.GetInt("points")-user.GetInt("points_floor")), 0)
+	deduction := min(penalty, available)
+	if deduction == 0 {
+		return nil
+	}
+
+	_, err = ledger.Record(txApp, user, -deduction, ledger.ReasonPenalty, entry)
+
+	return err
+}
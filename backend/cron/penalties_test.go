@@ -0,0 +1,49 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/ledger"
+)
+
+func TestPenaltyKeepsLockedAndFloorPoints(t *testing.T) {
+	cases := []struct {
+		name     string
+		locked   int
+		floor    int
+		expected int
+	}{
+		{"no limits", 0, 0, 5},
+		{"locked points", 8, 0, 8},
+		{"floor", 0, 9, 9},
+		{"locked under the floor", 4, 7, 7},
+		{"locked over the floor", 9, 3, 9},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			app := newTestApp(t)
+			user := testutil.NewUser(t, app, "", "user@example.com", 0)
+			if _, err := ledger.Record(app, user, 10, ledger.ReasonAward, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			user.Set("locked_points", c.locked)
+			user.Set("points_floor", c.floor)
+			if err := app.Save(user); err != nil {
+				t.Fatal(err)
+			}
+
+			newActivity(t, app, user, map[string]any{"penalty": 5})
+			if err := closePeriods(app, time.Now().Add(days(1))); err != nil {
+				t.Fatal(err)
+			}
+
+			if points := testutil.Reload(t, app, user).GetInt("points"); points != c.expected {
+				t.Errorf("Expected %d points after the penalty, got %d", c.expected, points)
+			}
+		})
+	}
+}
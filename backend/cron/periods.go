@@ -0,0 +1,384 @@
+package cron
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/approvals"
+	"github.com/dr4ghs/orgtool/challenges"
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/leaderboard"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/scoring"
+	"github.com/dr4ghs/orgtool/streak"
+	"github.com/dr4ghs/orgtool/webhooks"
+)
+
+// Upper bound of missed entries created for a single activity in one sweep
+const maxCatchUpPeriods = 400
+
+// CatchUp closes the periods that ended while the server was down, in order,
+// before the regular crons take over.
+func CatchUp(app core.App) {
+	if err := closePeriods(app, time.Now()); err != nil {
+		app.Logger().Error("Unable to catch up missed periods", "error", err)
+	}
+}
+
+func closePeriodsCron(app core.App) func() {
+	return func() {
+		if err := closePeriods(app, time.Now()); err != nil {
+			app.Logger().Error("Unable to close periods", "error", err)
+		}
+	}
+}
+
+// closePeriods closes every open entry whose period already ended in the
+// owner's local time and opens the entry of the current period.
+func closePeriods(app core.App, now time.Time) error {
+	users, err := app.FindAllRecords("users")
+	if err != nil {
+		return err
+	}
+
+	var closedUsers []string
+	for _, user := range users {
+		clock := period.UserClock(user)
+
+		closed := false
+		err := app.RunInTransaction(func(txApp core.App) error {
+			activities, err := txApp.FindAllRecords(
+				"activities",
+				dbx.HashExp{"user": user.Id},
+			)
+			if err != nil {
+				return err
+			}
+
+			for _, activity := range activities {
+				swept, err := sweepActivity(txApp, clock, activity, now)
+				if err != nil {
+					return err
+				}
+				closed = closed || swept
+			}
+
+			return updateWatermarks(txApp, clock, user, now)
+		})
+		if err != nil {
+			app.Logger().Error(
+				"Unable to close user periods",
+				"user", user.Id,
+				"error", err,
+			)
+			continue
+		}
+
+		if closed {
+			closedUsers = append(closedUsers, user.Id)
+		}
+	}
+
+	// Shared activities credit other members too, refresh all their groups
+	if err := leaderboard.RefreshUsers(app, closedUsers, now); err != nil {
+		app.Logger().Error("Unable to refresh leaderboards", "error", err)
+	}
+
+	// Every period within the ended challenges is closed by now
+	if err := challenges.FinishEnded(app, now); err != nil {
+		app.Logger().Error("Unable to finish challenges", "error", err)
+	}
+
+	return nil
+}
+
+// sweepActivity closes the ended entries of the activity and opens the
+// current one. It reports whether any period was closed.
+func sweepActivity(txApp core.App, clock period.Clock, activity *core.Record, now time.Time) (bool, error) {
+	// Oldest first, streaks depend on the order periods close in
+	records, err := txApp.FindRecordsByFilter(
+		entries.Collection,
+		"activity = {:activity} && closed = false",
+		"period_start,created",
+		0,
+		0,
+		dbx.Params{"activity": activity.Id},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	var last time.Time
+	open, closed := false, false
+	for _, entry := range records {
+		_, end, err := entries.Bounds(clock, activity, entry)
+		if err != nil {
+			return false, err
+		}
+
+		if now.Before(end) {
+			open = true
+			continue
+		}
+
+		if err := closeEntry(txApp, clock, activity, entry); err != nil {
+			return false, err
+		}
+		closed = true
+
+		if end.After(last) {
+			last = end
+		}
+	}
+
+	if open {
+		return closed, nil
+	}
+
+	if last.IsZero() {
+		last, err = lastPeriodEnd(txApp, clock, activity)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	missed, err := createMissedEntries(txApp, clock, activity, last, now)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = entries.Open(txApp, clock, activity, now)
+
+	return closed || missed > 0, err
+}
+
+// lastPeriodEnd returns where the activity history stops: the end of its
+// latest entry or, when it has none, the user's watermark. A zero time means
+// there is nothing to catch up.
+func lastPeriodEnd(txApp core.App, clock period.Clock, activity *core.Record) (time.Time, error) {
+	latest, err := txApp.FindRecordsByFilter(
+		entries.Collection,
+		"activity = {:activity}",
+		"-period_end,-created",
+		1,
+		0,
+		dbx.Params{"activity": activity.Id},
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(latest) > 0 {
+		_, end, err := entries.Bounds(clock, activity, latest[0])
+		return end, err
+	}
+
+	watermark, err := txApp.FindFirstRecordByFilter(
+		"period_watermarks",
+		"user = {:user} && period_type = {:type}",
+		dbx.Params{"user": activity.GetString("user"), "type": activity.GetString("type")},
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Never backfill before the activity existed
+	start, _, err := clock.Bounds(activity.GetString("type"), activity.GetDateTime("created").Time())
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	closedUntil := watermark.GetDateTime("closed_until").Time()
+	if closedUntil.Before(start) {
+		return start, nil
+	}
+
+	return closedUntil, nil
+}
+
+func createMissedEntries(
+	txApp core.App,
+	clock period.Clock,
+	activity *core.Record,
+	from time.Time,
+	now time.Time,
+) (created int, err error) {
+	if from.IsZero() {
+		return 0, nil
+	}
+
+	type bounds struct{ start, end time.Time }
+
+	var missed []bounds
+	for {
+		start, end, err := clock.Bounds(activity.GetString("type"), from)
+		if err != nil {
+			return 0, err
+		}
+
+		if now.Before(end) {
+			break
+		}
+		from = end
+
+		scheduled, err := entries.Scheduled(clock, activity, start)
+		if err != nil {
+			return 0, err
+		}
+
+		if scheduled {
+			missed = append(missed, bounds{start, end})
+		}
+	}
+
+	// The most recent periods are kept so the history has no gap before the
+	// entry opened next
+	if len(missed) > maxCatchUpPeriods {
+		txApp.Logger().Warn(
+			"Too many missed periods, skipping the oldest ones",
+			"activity", activity.Id,
+			"skipped", len(missed)-maxCatchUpPeriods,
+		)
+		missed = missed[len(missed)-maxCatchUpPeriods:]
+	}
+
+	for _, b := range missed {
+		entry, err := entries.Create(txApp, activity, b.start, b.end, true)
+		if err != nil {
+			return created, err
+		}
+		created++
+
+		if err := settleEntry(txApp, clock, activity, entry); err != nil {
+			return created, err
+		}
+	}
+
+	return created, nil
+}
+
+func closeEntry(txApp core.App, clock period.Clock, activity *core.Record, entry *core.Record) error {
+	entry.Set("closed", true)
+	if err := txApp.Save(entry); err != nil {
+		return err
+	}
+
+	return settleEntry(txApp, clock, activity, entry)
+}
+
+// settleEntry updates the activity streak and awards or penalizes the user
+// for a closed entry.
+func settleEntry(txApp core.App, clock period.Clock, activity *core.Record, entry *core.Record) error {
+	met := entry.GetInt("progress") >= entry.GetInt("goal")
+
+	// Periods missed while the server was down don't count against the user
+	downtime := entry.GetBool("missed")
+
+	if !downtime {
+		streak.Advance(activity, met)
+		if err := txApp.Save(activity); err != nil {
+			return err
+		}
+	}
+
+	if err := challenges.Track(txApp, clock, activity, entry); err != nil {
+		return err
+	}
+
+	err := webhooks.Emit(
+		txApp,
+		activity.GetString("user"),
+		webhooks.EventPeriodClosed,
+		map[string]any{"entry": entry, "activity": activity, "met": met},
+	)
+	if err != nil {
+		return err
+	}
+
+	user, err := txApp.FindRecordById("users", activity.GetString("user"))
+	if err != nil {
+		return err
+	}
+
+	if !met && !downtime {
+		if err := penalize(txApp, clock, user, activity, entry); err != nil {
+			return err
+		}
+	}
+
+	rules, err := scoring.ActivityRules(activity)
+	if err != nil {
+		return err
+	}
+
+	// Partial credit can be earned even when the goal wasn't met
+	points := rules.Score(activity.GetInt("points"), entry.GetInt("progress"), entry.GetInt("goal"))
+	points = streak.ActivitySchedule(activity).Apply(points, activity.GetInt("streak"))
+	if points <= 0 {
+		return nil
+	}
+
+	// Shared activities credit the member that completed them
+	if completedBy := entry.GetString("completed_by"); completedBy != "" && completedBy != user.Id {
+		user, err = txApp.FindRecordById("users", completedBy)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Supervised users wait for the award to be approved
+	if approvals.Required(user) {
+		_, err = approvals.RequestAward(txApp, user, entry, points)
+		return err
+	}
+
+	_, err = ledger.Record(txApp, user, points, ledger.ReasonAward, entry)
+
+	return err
+}
+
+// updateWatermarks moves the user's watermarks to the start of the current
+// periods, everything before them being closed by now.
+func updateWatermarks(txApp core.App, clock period.Clock, user *core.Record, now time.Time) error {
+	collection, err := txApp.FindCollectionByNameOrId("period_watermarks")
+	if err != nil {
+		return err
+	}
+
+	for _, typ := range period.Types {
+		closedUntil, _, err := clock.Bounds(typ, now)
+		if err != nil {
+			return err
+		}
+
+		watermark, err := txApp.FindFirstRecordByFilter(
+			collection,
+			"user = {:user} && period_type = {:type}",
+			dbx.Params{"user": user.Id, "type": typ},
+		)
+		if err != nil {
+			watermark = core.NewRecord(collection)
+			watermark.Set("user", user.Id)
+			watermark.Set("period_type", typ)
+		}
+
+		if watermark.GetDateTime("closed_until").Time().Equal(closedUntil) {
+			continue
+		}
+
+		watermark.Set("closed_until", closedUntil)
+		if err := txApp.Save(watermark); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+
+	"github.com/dr4ghs/orgtool/approvals"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+)
+
+func TestDowntimeDoesNotPenalize(t *testing.T) {
+	app := newTestApp(t)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	activity := newActivity(t, app, user, map[string]any{"penalty": 3})
+
+	complete(t, app, openEntry(t, app, activity))
+	if err := closePeriods(app, time.Now().Add(days(6))); err != nil {
+		t.Fatal(err)
+	}
+
+	var missed int
+	for _, entry := range activityEntries(t, app, activity) {
+		if entry.GetBool("missed") {
+			missed++
+		}
+	}
+	if missed != 5 {
+		t.Fatalf("Expected 5 missed entries, got %d", missed)
+	}
+
+	if streak := testutil.Reload(t, app, activity).GetInt("streak"); streak != 1 {
+		t.Errorf("Downtime changed the streak to %d", streak)
+	}
+
+	if n := countTransactions(t, app, user, ledger.ReasonPenalty); n != 0 {
+		t.Errorf("Downtime was penalized %d times", n)
+	}
+}
+
+func TestOutageCatchUp(t *testing.T) {
+	app := newTestApp(t)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	activity := newActivity(t, app, user, nil)
+
+	complete(t, app, openEntry(t, app, activity))
+
+	// Down for 10 days, then the sweeper runs twice
+	now := time.Now().Add(days(10))
+	for i := 0; i < 2; i++ {
+		if err := closePeriods(app, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := activityEntries(t, app, activity)
+	if len(list) != 11 {
+		t.Fatalf("Expected 11 entries, got %d", len(list))
+	}
+
+	for i, entry := range list {
+		switch {
+		case i == 0:
+			if !entry.GetBool("closed") || entry.GetBool("missed") {
+				t.Errorf("The entry open before the outage wasn't closed normally")
+			}
+		case i == len(list)-1:
+			if entry.GetBool("closed") {
+				t.Errorf("The current entry is closed")
+			}
+			if end := entry.GetDateTime("period_end").Time(); !now.Before(end) {
+				t.Errorf("The current entry ends at %s, before %s", end, now)
+			}
+		default:
+			if !entry.GetBool("closed") || !entry.GetBool("missed") {
+				t.Errorf("Entry %d is not a closed missed entry", i)
+			}
+		}
+
+		if i > 0 && !entry.GetDateTime("period_start").Time().Equal(list[i-1].GetDateTime("period_end").Time()) {
+			t.Errorf("Gap between entries %d and %d", i-1, i)
+		}
+	}
+
+	if points := testutil.Reload(t, app, user).GetInt("points"); points != 5 {
+		t.Errorf("Expected the 5 points of the completed entry, got %d", points)
+	}
+}
+
+func TestLongOutageKeepsNewestPeriods(t *testing.T) {
+	app := newTestApp(t)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	activity := newActivity(t, app, user, nil)
+
+	if err := closePeriods(app, time.Now().Add(days(maxCatchUpPeriods+30))); err != nil {
+		t.Fatal(err)
+	}
+
+	list := activityEntries(t, app, activity)
+
+	var missed int
+	for _, entry := range list {
+		if entry.GetBool("missed") {
+			missed++
+		}
+	}
+	if missed != maxCatchUpPeriods {
+		t.Fatalf("Expected %d missed entries, got %d", maxCatchUpPeriods, missed)
+	}
+
+	// The skipped periods are the oldest ones, right after the first entry
+	current := list[len(list)-1]
+	last := list[len(list)-2]
+	if !last.GetBool("missed") || !last.GetDateTime("period_end").Time().Equal(current.GetDateTime("period_start").Time()) {
+		t.Errorf("The newest missed entry doesn't reach the current one")
+	}
+
+	if !list[1].GetDateTime("period_start").Time().After(list[0].GetDateTime("period_end").Time()) {
+		t.Errorf("The oldest periods weren't skipped")
+	}
+}
+
+func TestLastPeriodEndWithoutHistory(t *testing.T) {
+	app := newTestApp(t)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	activity := newActivity(t, app, user, nil)
+
+	for _, entry := range activityEntries(t, app, activity) {
+		if err := app.Delete(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := lastPeriodEnd(app, period.UserClock(user), activity); err != nil {
+		t.Fatalf("No history returned %v", err)
+	}
+
+	if err := closePeriods(app, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(activityEntries(t, app, activity)) != 1 {
+		t.Errorf("The current entry wasn't opened again")
+	}
+}
+
+func TestStreakBreaksWhenPeriodsClose(t *testing.T) {
+	cases := []struct {
+		typ    string
+		length time.Duration
+	}{
+		{"daily", days(1)},
+		{"weekly", days(7)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.typ, func(t *testing.T) {
+			app := newTestApp(t)
+			user := testutil.NewUser(t, app, "", "user@example.com", 0)
+			activity := newActivity(t, app, user, map[string]any{
+				"type":         c.typ,
+				"streak_step":  2,
+				"streak_bonus": 50,
+			})
+
+			// Met, met, missed, met
+			steps := []struct {
+				met    bool
+				streak int
+				best   int
+				points int
+			}{
+				{true, 1, 1, 5},
+				{true, 2, 2, 13},
+				{false, 0, 2, 13},
+				{true, 1, 2, 18},
+			}
+
+			now := time.Now()
+			for i, step := range steps {
+				if step.met {
+					complete(t, app, openEntry(t, app, activity))
+				}
+
+				now = now.Add(c.length)
+				if err := closePeriods(app, now); err != nil {
+					t.Fatal(err)
+				}
+
+				activity = testutil.Reload(t, app, activity)
+				if activity.GetInt("streak") != step.streak || activity.GetInt("best_streak") != step.best {
+					t.Fatalf(
+						"Period %d: streak %d, best %d, expected %d, %d",
+						i, activity.GetInt("streak"), activity.GetInt("best_streak"), step.streak, step.best,
+					)
+				}
+
+				if points := testutil.Reload(t, app, user).GetInt("points"); points != step.points {
+					t.Fatalf("Period %d: %d points, expected %d", i, points, step.points)
+				}
+			}
+		})
+	}
+}
+
+func TestSupervisedAwardWaitsForApproval(t *testing.T) {
+	app := newTestApp(t)
+	supervisor := testutil.NewUser(t, app, "", "supervisor@example.com", 0)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	user.Set("requires_approval", true)
+	user.Set("supervisors", []string{supervisor.Id})
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	activity := newActivity(t, app, user, nil)
+	entry := openEntry(t, app, activity)
+	complete(t, app, entry)
+
+	if err := closePeriods(app, time.Now().Add(days(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	if points := testutil.Reload(t, app, user).GetInt("points"); points != 0 {
+		t.Errorf("Points were awarded before the approval: %d", points)
+	}
+
+	if !testutil.Reload(t, app, entry).GetBool("pending_approval") {
+		t.Errorf("The entry isn't pending approval")
+	}
+
+	approval, err := app.FindFirstRecordByFilter(
+		approvals.Collection,
+		"entry = {:entry} && status = {:status}",
+		dbx.Params{"entry": entry.Id, "status": approvals.StatusPending},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approval.GetInt("points") != 5 {
+		t.Errorf("The approval holds %d points", approval.GetInt("points"))
+	}
+}
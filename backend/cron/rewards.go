@@ -0,0 +1,59 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+func resetRewardsCron(app core.App) func() {
+	return func() {
+		if err := resetRewards(app, time.Now()); err != nil {
+			app.Logger().Error("Unable to reset rewards", "error", err)
+		}
+	}
+}
+
+// resetRewards resets every reward whose window rolled over in the owner's
+// local time.
+func resetRewards(app core.App, now time.Time) error {
+	users, err := app.FindAllRecords("users")
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		clock := period.UserClock(user)
+
+		err := app.RunInTransaction(func(txApp core.App) error {
+			records, err := txApp.FindAllRecords(
+				rewards.Collection,
+				dbx.HashExp{"user": user.Id},
+			)
+			if err != nil {
+				return err
+			}
+
+			for _, reward := range records {
+				if _, err := rewards.Reset(txApp, clock, reward, now); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			app.Logger().Error(
+				"Unable to reset user rewards",
+				"user", user.Id,
+				"error", err,
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/internal/testutil"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+// newReward creates a reward of the user with some units redeemed and used,
+// last reset when created like the create request does.
+func newReward(t testing.TB, app core.App, user *core.Record, fields map[string]any) *core.Record {
+	reward := map[string]any{
+		"name":            "Ice cream",
+		"user":            user.Id,
+		"unit_cost":       1,
+		"max_redeemables": 5,
+		"redeemed":        2,
+		"used":            1,
+		"last_reset":      time.Now(),
+	}
+	for k, v := range fields {
+		reward[k] = v
+	}
+
+	return testutil.NewRecord(t, app, rewards.Collection, "", reward)
+}
+
+// expectReset runs the reset at the instant before and at the rollover, the
+// reward counters being cleared at the rollover only.
+func expectReset(t testing.TB, app core.App, reward *core.Record, rollover time.Time) {
+	if err := resetRewards(app, rollover.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if redeemed := testutil.Reload(t, app, reward).GetInt("redeemed"); redeemed != 2 {
+		t.Fatalf("Reset before %s", rollover)
+	}
+
+	if err := resetRewards(app, rollover); err != nil {
+		t.Fatal(err)
+	}
+	reward = testutil.Reload(t, app, reward)
+	if reward.GetInt("redeemed") != 0 || reward.GetInt("used") != 0 {
+		t.Fatalf("Not reset at %s: %d redeemed, %d used", rollover, reward.GetInt("redeemed"), reward.GetInt("used"))
+	}
+	if !reward.GetDateTime("last_reset").Time().Equal(rollover.Truncate(time.Millisecond)) {
+		t.Errorf("Last reset at %s", reward.GetDateTime("last_reset"))
+	}
+}
+
+func TestResetPeriods(t *testing.T) {
+	for _, typ := range []string{rewards.ResetDaily, rewards.ResetWeekly, rewards.ResetMonthly} {
+		t.Run(typ, func(t *testing.T) {
+			app := newTestApp(t)
+			user := testutil.NewUser(t, app, "", "user@example.com", 0)
+			reward := newReward(t, app, user, map[string]any{"reset_period": typ})
+
+			_, end, err := period.UserClock(user).Bounds(typ, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectReset(t, app, reward, end)
+		})
+	}
+}
+
+func TestResetNever(t *testing.T) {
+	app := newTestApp(t)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	reward := newReward(t, app, user, map[string]any{"reset_period": rewards.ResetNever})
+
+	if err := resetRewards(app, time.Now().Add(days(400))); err != nil {
+		t.Fatal(err)
+	}
+
+	if redeemed := testutil.Reload(t, app, reward).GetInt("redeemed"); redeemed != 2 {
+		t.Errorf("A reward never reset has %d redeemed", redeemed)
+	}
+}
+
+func TestResetCustomRule(t *testing.T) {
+	cases := []struct {
+		rule string
+		// Days from today to the next rollover
+		next func(today time.Time) int
+	}{
+		{
+			rule: "FREQ=DAILY;INTERVAL=3",
+			next: func(today time.Time) int { return 3 },
+		},
+		{
+			rule: "FREQ=WEEKLY;BYDAY=WE",
+			next: func(today time.Time) int {
+				n := (int(time.Wednesday) - int(today.Weekday()) + 7) % 7
+				if n == 0 {
+					n = 7
+				}
+				return n
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.rule, func(t *testing.T) {
+			app := newTestApp(t)
+			user := testutil.NewUser(t, app, "", "user@example.com", 0)
+			reward := newReward(t, app, user, map[string]any{
+				"reset_period": rewards.ResetCustom,
+				"reset_rule":   c.rule,
+			})
+
+			clock := period.UserClock(user)
+			today := clock.Day(time.Now())
+
+			expectReset(t, app, reward, clock.StartOf(today.AddDate(0, 0, c.next(today))))
+		})
+	}
+}
+
+func TestResetOwnerClock(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTestApp(t)
+	user := testutil.NewUser(t, app, "", "user@example.com", 0)
+	user.Set("timezone", tokyo.String())
+	user.Set("day_start", "04:00")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	reward := newReward(t, app, user, map[string]any{"reset_period": rewards.ResetDaily})
+
+	// The next 04:00 in Tokyo
+	now := time.Now().In(tokyo)
+	rollover := time.Date(now.Year(), now.Month(), now.Day(), 4, 0, 0, 0, tokyo)
+	if !rollover.After(now) {
+		rollover = rollover.AddDate(0, 0, 1)
+	}
+
+	expectReset(t, app, reward, rollover)
+}
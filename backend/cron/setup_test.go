@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/internal/testutil"
+)
+
+func newTestApp(t testing.TB) *tests.TestApp {
+	app := testutil.NewApp(t)
+	t.Cleanup(app.Cleanup)
+
+	return app
+}
+
+// newActivity creates a daily activity of the user, opening its first entry.
+func newActivity(t testing.TB, app core.App, user *core.Record, fields map[string]any) *core.Record {
+	activity := map[string]any{
+		"name":   "Run",
+		"user":   user.Id,
+		"type":   "daily",
+		"goal":   1,
+		"points": 5,
+	}
+	for k, v := range fields {
+		activity[k] = v
+	}
+
+	return testutil.NewRecord(t, app, "activities", "", activity)
+}
+
+func activityEntries(t testing.TB, app core.App, activity *core.Record) []*core.Record {
+	list, err := app.FindRecordsByFilter(
+		entries.Collection,
+		"activity = {:activity}",
+		"period_start",
+		0,
+		0,
+		dbx.Params{"activity": activity.Id},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return list
+}
+
+// openEntry returns the only open entry of the activity.
+func openEntry(t testing.TB, app core.App, activity *core.Record) *core.Record {
+	entry, err := app.FindFirstRecordByFilter(
+		entries.Collection,
+		"activity = {:activity} && closed = false",
+		dbx.Params{"activity": activity.Id},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return entry
+}
+
+func complete(t testing.TB, app core.App, entry *core.Record) {
+	entry.Set("progress", entry.GetInt("goal"))
+	if err := app.Save(entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countTransactions(t testing.TB, app core.App, user *core.Record, reason string) int {
+	n, err := app.CountRecords("point_transactions", dbx.HashExp{"user": user.Id, "reason": reason})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return int(n)
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
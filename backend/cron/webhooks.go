@@ -0,0 +1,23 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/webhooks"
+)
+
+func deliverWebhooksCron(app core.App) func() {
+	return func() {
+		delivered, err := webhooks.Deliver(app, webhooks.Client, time.Now())
+		if err != nil {
+			app.Logger().Error("Unable to deliver webhooks", "error", err)
+			return
+		}
+
+		if delivered > 0 {
+			app.Logger().Debug("Delivered webhooks", "count", delivered)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package entries
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/recurrence"
+)
+
+const Collection = "entries"
+
+func Clock(app core.App, activity *core.Record) (period.Clock, error) {
+	user, err := app.FindRecordById("users", activity.GetString("user"))
+	if err != nil {
+		return period.Clock{}, err
+	}
+
+	return period.UserClock(user), nil
+}
+
+// Bounds returns the period of the entry, falling back to the one it was
+// created in for entries that predate the period fields.
+func Bounds(clock period.Clock, activity *core.Record, entry *core.Record) (time.Time, time.Time, error) {
+	start := entry.GetDateTime("period_start")
+	end := entry.GetDateTime("period_end")
+	if !start.IsZero() && !end.IsZero() {
+		return start.Time(), end.Time(), nil
+	}
+
+	typ := entry.GetString("period_type")
+	if typ == "" {
+		typ = activity.GetString("type")
+	}
+
+	return clock.Bounds(typ, entry.GetDateTime("created").Time())
+}
+
+// Create adds the entry of the given period. Missed entries are created
+// already closed as the period ended while nobody was there to open it.
+func Create(
+	app core.App,
+	activity *core.Record,
+	start time.Time,
+	end time.Time,
+	missed bool,
+) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("activity", activity.Id)
+	record.Set("period_type", activity.GetString("type"))
+	record.Set("progress", 0)
+	record.Set("goal", activity.GetInt("goal"))
+	record.Set("closed", missed)
+	record.Set("missed", missed)
+	record.Set("period_start", start)
+	record.Set("period_end", end)
+
+	if err := app.Save(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Open creates the entry of the period containing now, if the activity is
+// scheduled for it. It returns a nil record otherwise.
+func Open(app core.App, clock period.Clock, activity *core.Record, now time.Time) (*core.Record, error) {
+	start, end, err := clock.Bounds(activity.GetString("type"), now)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := Scheduled(clock, activity, start)
+	if err != nil || !scheduled {
+		return nil, err
+	}
+
+	return Create(app, activity, start, end, false)
+}
+
+// Scheduled reports whether the activity has an entry in the period starting
+// at start. Activities without a schedule have one in every period.
+func Scheduled(clock period.Clock, activity *core.Record, start time.Time) (bool, error) {
+	schedule := activity.GetString("schedule")
+	if schedule == "" {
+		return true, nil
+	}
+
+	rule, err := recurrence.Parse(schedule)
+	if err != nil {
+		return false, err
+	}
+
+	// The rule starts with the period the activity was created in
+	dtstart, _, err := clock.Bounds(activity.GetString("type"), activity.GetDateTime("created").Time())
+	if err != nil {
+		return false, err
+	}
+
+	return rule.Occurs(dtstart.In(clock.Location), start.In(clock.Location)), nil
+}
@@ -0,0 +1,122 @@
+package entries
+
+import (
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/webhooks"
+)
+
+// Times an increment is retried when another one lands in between
+const maxIncrementAttempts = 3
+
+var (
+	ErrClosed   = errors.New("Is not possible to update a closed entry")
+	ErrConflict = errors.New("The entry was updated concurrently, try again")
+)
+
+// Increment adds delta to the entry progress, never going below zero, and
+// records who did it, queueing the entry.completed event when it reaches the
+// goal. The write only succeeds if the entry didn't change since it was read;
+// being relative, the increment is simply retried otherwise.
+func Increment(app core.App, entryId string, user *core.Record, delta int) (entry *core.Record, err error) {
+	for attempt := 0; attempt < maxIncrementAttempts; attempt++ {
+		err = app.RunInTransaction(func(txApp core.App) error {
+			entry, err = txApp.FindRecordById(Collection, entryId)
+			if err != nil {
+				return err
+			}
+
+			if entry.GetBool("closed") {
+				return ErrClosed
+			}
+
+			progress := max(entry.GetInt("progress")+delta, 0)
+			// The floor at zero can take less than asked
+			applied := progress - entry.GetInt("progress")
+			now := types.NowDateTime()
+
+			completed := Completes(entry, progress)
+
+			params := dbx.Params{"progress": progress, "updated": now}
+			switch {
+			case completed:
+				params["completed_by"] = user.Id
+			case progress < entry.GetInt("goal"):
+				params["completed_by"] = ""
+			}
+
+			result, err := txApp.DB().Update(
+				Collection,
+				params,
+				dbx.HashExp{
+					"id":      entry.Id,
+					"closed":  false,
+					"updated": entry.GetDateTime("updated").String(),
+				},
+			).Execute()
+			if err != nil {
+				return err
+			}
+
+			if affected, _ := result.RowsAffected(); affected == 0 {
+				return ErrConflict
+			}
+
+			for field, value := range params {
+				entry.Set(field, value)
+			}
+
+			if err := saveProgressEvent(txApp, entry, user, applied); err != nil {
+				return err
+			}
+
+			// The direct write skips the model hook queueing it
+			if !completed {
+				return nil
+			}
+
+			activity, err := txApp.FindRecordById("activities", entry.GetString("activity"))
+			if err != nil {
+				return err
+			}
+
+			return webhooks.Emit(
+				txApp,
+				activity.GetString("user"),
+				webhooks.EventEntryCompleted,
+				map[string]any{"entry": entry, "activity": activity},
+			)
+		})
+
+		if !errors.Is(err, ErrConflict) {
+			break
+		}
+	}
+
+	return entry, err
+}
+
+// Completes reports whether moving the entry to progress reaches its goal.
+func Completes(entry *core.Record, progress int) bool {
+	goal := entry.GetInt("goal")
+	return entry.GetInt("progress") < goal && progress >= goal
+}
+
+func saveProgressEvent(txApp core.App, entry *core.Record, user *core.Record, delta int) error {
+	collection, err := txApp.FindCollectionByNameOrId("progress_events")
+	if err != nil {
+		return err
+	}
+
+	event := core.NewRecord(collection)
+	event.Set("entry", entry.Id)
+	event.Set("user", user.Id)
+	event.Set("delta", delta)
+	event.Set("progress", entry.GetInt("progress"))
+
+	return txApp.Save(event)
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand returns the "export" command writing the archive of a user,
+// found by id or email, to a file.
+func NewCommand(app core.App) *cobra.Command {
+	var format, output string
+
+	command := &cobra.Command{
+		Use:          "export <user id or email>",
+		Example:      "export test@example.com --format csv --output export.zip",
+		Short:        "Exports the data of a user to a zip archive",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(command *cobra.Command, args []string) error {
+			user, err := app.FindRecordById("users", args[0])
+			if err != nil {
+				user, err = app.FindAuthRecordByEmail("users", args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("User '%s' not found", args[0])
+			}
+
+			now := time.Now()
+			if output == "" {
+				output = FileName(user, now)
+			}
+
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if err := Write(app, file, user, format, now); err != nil {
+				os.Remove(output)
+				return err
+			}
+
+			fmt.Fprintf(command.OutOrStdout(), "Exported user %s to %s\n", user.Id, output)
+
+			return file.Close()
+		},
+	}
+
+	command.Flags().StringVar(&format, "format", FormatJSON, "format of the exported files (csv or json)")
+	command.Flags().StringVarP(&output, "output", "o", "", "archive path, orgtool-export-<user>-<date>.zip by default")
+
+	return command
+}
+
+// FileName returns the default name of the archive.
+func FileName(user *core.Record, now time.Time) string {
+	return fmt.Sprintf("orgtool-export-%s-%s.zip", user.Id, now.UTC().Format("20060102"))
+}
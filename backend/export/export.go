@@ -0,0 +1,229 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/period"
+)
+
+// SchemaVersion is bumped whenever a file of the archive changes layout, see
+// the README for the history.
+const SchemaVersion = 1
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var Formats = []string{FormatCSV, FormatJSON}
+
+const ManifestFile = "manifest.json"
+
+// Manifest describes the archive content, it is always written as JSON.
+type Manifest struct {
+	SchemaVersion int    `json:"schema_version"`
+	Format        string `json:"format"`
+	User          string `json:"user"`
+	ExportedAt    string `json:"exported_at"`
+	Files         []File `json:"files"`
+}
+
+type File struct {
+	Name       string   `json:"name"`
+	Collection string   `json:"collection"`
+	Records    int      `json:"records"`
+	Fields     []string `json:"fields"`
+}
+
+type table struct {
+	name       string
+	collection string
+	filter     string
+	params     dbx.Params
+}
+
+func tables(userId string) []table {
+	user := dbx.Params{"user": userId}
+
+	list := []table{
+		{"activities", "activities", "user = {:user}", user},
+	}
+
+	// One file per period type, like the collections entries replaced
+	for _, typ := range period.Types {
+		list = append(list, table{
+			typ + "_entries",
+			"entries",
+			"activity.user = {:user} && period_type = {:type}",
+			dbx.Params{"user": userId, "type": typ},
+		})
+	}
+
+	return append(
+		list,
+		table{"rewards", "rewards", "user = {:user}", user},
+		table{"redemptions", "redemptions", "user = {:user}", user},
+		table{"point_transactions", "point_transactions", "user = {:user}", user},
+	)
+}
+
+// Write writes the zip archive with the data of the user in the given format.
+func Write(app core.App, w io.Writer, user *core.Record, format string, now time.Time) error {
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("Not known export format '%s'", format)
+	}
+
+	archive := zip.NewWriter(w)
+
+	manifest := Manifest{
+		SchemaVersion: SchemaVersion,
+		Format:        format,
+		User:          user.Id,
+		ExportedAt:    now.UTC().Format(time.RFC3339),
+		Files:         []File{},
+	}
+
+	for _, t := range tables(user.Id) {
+		file, err := writeTable(app, archive, t, format, now)
+		if err != nil {
+			return err
+		}
+
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	out, err := create(archive, ManifestFile, now)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeTable(app core.App, archive *zip.Writer, t table, format string, now time.Time) (File, error) {
+	collection, err := app.FindCollectionByNameOrId(t.collection)
+	if err != nil {
+		return File{}, err
+	}
+
+	// Some of the older collections have no created field
+	sort := "id"
+	if collection.Fields.GetByName("created") != nil {
+		sort = "created,id"
+	}
+
+	records, err := app.FindRecordsByFilter(collection, t.filter, sort, 0, 0, t.params)
+	if err != nil {
+		return File{}, err
+	}
+
+	file := File{
+		Name:       t.name + "." + format,
+		Collection: t.collection,
+		Records:    len(records),
+		Fields:     fields(collection),
+	}
+
+	out, err := create(archive, file.Name, now)
+	if err != nil {
+		return File{}, err
+	}
+
+	if format == FormatJSON {
+		rows := make([]map[string]any, 0, len(records))
+		for _, record := range records {
+			row := make(map[string]any, len(file.Fields))
+			for _, field := range file.Fields {
+				row[field] = record.Get(field)
+			}
+			rows = append(rows, row)
+		}
+
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return file, encoder.Encode(rows)
+	}
+
+	writer := csv.NewWriter(out)
+	if err := writer.Write(file.Fields); err != nil {
+		return File{}, err
+	}
+
+	for _, record := range records {
+		row := make([]string, len(file.Fields))
+		for i, field := range file.Fields {
+			row[i], err = cell(record.Get(field))
+			if err != nil {
+				return File{}, err
+			}
+		}
+
+		if err := writer.Write(row); err != nil {
+			return File{}, err
+		}
+	}
+	writer.Flush()
+
+	return file, writer.Error()
+}
+
+// =============================================================================
+// HELPERS
+//
+
+// fields returns the exported fields of the collection, the hidden ones
+// staying out as they do in the API.
+func fields(collection *core.Collection) []string {
+	var names []string
+	for _, field := range collection.Fields {
+		if field.GetHidden() {
+			continue
+		}
+
+		names = append(names, field.GetName())
+	}
+
+	return names
+}
+
+// cell formats a value for CSV, lists and objects being written as JSON.
+func cell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool, int, int64, float64:
+		return fmt.Sprint(v), nil
+	}
+
+	out, err := json.Marshal(value)
+
+	return string(out), err
+}
+
+func create(archive *zip.Writer, name string, now time.Time) (io.Writer, error) {
+	return archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: now,
+	})
+}
@@ -0,0 +1,73 @@
+package groups
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	Collection        = "groups"
+	MembersCollection = "group_members"
+)
+
+const (
+	// The user that created the group
+	RoleOwner = "owner"
+	// Manages the shared activities and rewards
+	RoleAdmin = "admin"
+	// Sees and progresses the shared activities, redeems the shared rewards
+	RoleMember = "member"
+)
+
+var Roles = []string{RoleOwner, RoleAdmin, RoleMember}
+
+// Role returns the role of the user in the group, empty if not a member.
+func Role(app core.App, groupId string, userId string) string {
+	member, err := app.FindFirstRecordByFilter(
+		MembersCollection,
+		"group = {:group} && user = {:user}",
+		dbx.Params{"group": groupId, "user": userId},
+	)
+	if err != nil {
+		return ""
+	}
+
+	return member.GetString("role")
+}
+
+func IsMember(app core.App, groupId string, userId string) bool {
+	return groupId != "" && Role(app, groupId, userId) != ""
+}
+
+// CanManage reports whether the user can add, change and remove the shared
+// items of the group.
+func CanManage(app core.App, groupId string, userId string) bool {
+	if groupId == "" {
+		return false
+	}
+
+	role := Role(app, groupId, userId)
+
+	return role == RoleOwner || role == RoleAdmin
+}
+
+// CanAccess reports whether the user can see and use an activity or reward:
+// either it is theirs or it is shared with one of their groups.
+func CanAccess(app core.App, record *core.Record, userId string) bool {
+	return record.GetString("user") == userId || IsMember(app, record.GetString("group"), userId)
+}
+
+// AddMember adds the user to the group with the given role.
+func AddMember(app core.App, groupId string, userId string, role string) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(MembersCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	member := core.NewRecord(collection)
+	member.Set("group", groupId)
+	member.Set("user", userId)
+	member.Set("role", role)
+
+	return member, app.Save(member)
+}
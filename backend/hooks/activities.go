@@ -0,0 +1,326 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/recurrence"
+	"github.com/dr4ghs/orgtool/scoring"
+)
+
+// =============================================================================
+// ACTIVITIES
+//
+
+func createActivitiesHooks(app core.App) {
+	app.OnRecordAfterCreateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "activities_onCreate",
+		Func: func(e *core.RecordEvent) error {
+			collection, err := e.App.FindCollectionByNameOrId("daily_entries")
+			if err != nil {
+				return err
+			}
+
+			record := core.NewRecord(collection)
+			record.Set("activity", e.Record.Id)
+			record.Set("progress", 0)
+			record.Set("goal", e.Record.Get("goal"))
+			record.Set("closed", false)
+
+			if err := e.App.Save(record); err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func injectActivityUserHookBind(app core.App) {
+	app.OnRecordCreateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "activities-onCreateRequest_injectUser",
+		Func: func(e *core.RecordRequestEvent) error {
+			e.Record.Set("user", e.Auth.Id)
+
+			return e.Next()
+		},
+	})
+}
+
+func createActivityEntryHookBind(app core.App) {
+	app.OnRecordAfterCreateSuccess("activities").Unbind("activities_onCreate")
+	app.OnRecordAfterCreateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "activities-onCreateSuccess_createEntry",
+		Func: func(e *core.RecordEvent) error {
+			typ := e.Record.GetString("type")
+			if typ != "daily" && typ != "weekly" && typ != "monthly" && typ != "yearly" {
+				return fmt.Errorf("Not known activity type '%s'\n", typ)
+			}
+
+			collection, err := e.App.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", typ))
+			if err != nil {
+				return err
+			}
+
+			record := core.NewRecord(collection)
+			record.Set("activity", e.Record.Id)
+			record.Set("progress", 0)
+			record.Set("goal", e.Record.GetInt("goal"))
+			record.Set("closed", false)
+
+			if err := e.App.Save(record); err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func preventActivityOwnerChangeHookBind(app core.App) {
+	app.OnRecordUpdateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "activities-onUpdateRequest_changeOwner",
+		Func: func(e *core.RecordRequestEvent) error {
+			activity, err := e.App.FindRecordById("activities", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			if activity.GetString("user") != e.Record.GetString("user") {
+				return fmt.Errorf("Cannot change activity owner")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func changeActivityTypeHookBind(app core.App) {
+	app.OnRecordAfterCreateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "activities-onCreateSuccess_changeActivity",
+		Func: func(e *core.RecordEvent) error {
+			activity, err := e.App.FindRecordById("activities", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			oldType := activity.GetString("type")
+			newType := e.Record.GetString("type")
+			if oldType == newType {
+				return e.Next()
+			}
+
+			if oldType != "daily" && oldType != "weekly" && oldType != "monthly" &&
+				oldType != "yearly" {
+				return fmt.Errorf("Unknown activity type of '%s'", oldType)
+			}
+
+			oldRecord, err := e.App.FindFirstRecordByFilter(
+				fmt.Sprintf("%s_entries", oldType),
+				"closed = False",
+			)
+			if err != nil {
+				return err
+			}
+
+			collection, err := e.App.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", newType))
+			if err != nil {
+				return err
+			}
+
+			newRecord := core.NewRecord(collection)
+			newRecord.Set("activity", e.Record.Id)
+			newRecord.Set("progress", oldRecord.GetInt("progress"))
+			newRecord.Set("goal", oldRecord.GetInt("goal"))
+			newRecord.Set("closed", false)
+
+			if err := e.App.Delete(oldRecord); err != nil {
+				return err
+			}
+
+			if err := e.App.Save(newRecord); err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// Opens the entry of the current period of the activity, in the collection of
+// its type
+func createActivityPeriodEntryHookBind(app core.App) {
+	app.OnRecordAfterCreateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "activities-onCreateSuccess_createEntry",
+		Func: func(e *core.RecordEvent) error {
+			typ := e.Record.GetString("type")
+
+			collection, err := e.App.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", typ))
+			if err != nil {
+				return err
+			}
+
+			clock, err := entries.Clock(e.App, e.Record)
+			if err != nil {
+				return err
+			}
+
+			start, end, err := clock.Bounds(typ, time.Now())
+			if err != nil {
+				return err
+			}
+
+			record := core.NewRecord(collection)
+			record.Set("activity", e.Record.Id)
+			record.Set("progress", 0)
+			record.Set("goal", e.Record.GetInt("goal"))
+			record.Set("closed", false)
+			record.Set("period_start", start)
+			record.Set("period_end", end)
+
+			if err := e.App.Save(record); err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// Opens the entry of the current period of the activity, if scheduled for it
+func openActivityEntryHookBind(app core.App) {
+	app.OnRecordAfterCreateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "activities-onCreateSuccess_createEntry",
+		Func: func(e *core.RecordEvent) error {
+			clock, err := entries.Clock(e.App, e.Record)
+			if err != nil {
+				return err
+			}
+
+			if _, err := entries.Open(e.App, clock, e.Record, time.Now()); err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkActivityScheduleRequest(e *core.RecordRequestEvent) error {
+	if schedule := e.Record.GetString("schedule"); schedule != "" {
+		if _, err := recurrence.Parse(schedule); err != nil {
+			return fmt.Errorf("Invalid activity schedule: %w", err)
+		}
+	}
+
+	return e.Next()
+}
+
+func checkActivityScheduleHookBind(app core.App) {
+	app.OnRecordCreateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "activities-onCreateRequest_checkSchedule",
+		Func: checkActivityScheduleRequest,
+	})
+
+	app.OnRecordUpdateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "activities-onUpdateRequest_checkSchedule",
+		Func: checkActivityScheduleRequest,
+	})
+}
+
+func changeEntryTypeHookBind(app core.App) {
+	app.OnRecordAfterCreateSuccess("activities").Unbind("activities-onCreateSuccess_changeActivity")
+	app.OnRecordAfterUpdateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "activities-onUpdateSuccess_changeType",
+		Func: func(e *core.RecordEvent) error {
+			if e.Record.Original().GetString("type") == e.Record.GetString("type") {
+				return e.Next()
+			}
+
+			open, err := e.App.FindAllRecords(
+				entries.Collection,
+				dbx.HashExp{"activity": e.Record.Id, "closed": false},
+			)
+			if err != nil {
+				return err
+			}
+
+			// Progress carries over to the entry of the new period type
+			progress := 0
+			for _, entry := range open {
+				progress = max(progress, entry.GetInt("progress"))
+
+				if err := e.App.Delete(entry); err != nil {
+					return err
+				}
+			}
+
+			clock, err := entries.Clock(e.App, e.Record)
+			if err != nil {
+				return err
+			}
+
+			entry, err := entries.Open(e.App, clock, e.Record, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if entry != nil && progress > 0 {
+				entry.Set("progress", progress)
+				if err := e.App.Save(entry); err != nil {
+					return err
+				}
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func preventActivityStreakChangeHookBind(app core.App) {
+	app.OnRecordUpdateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "activities-onUpdateRequest_changeStreak",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.HasSuperuserAuth() {
+				return e.Next()
+			}
+
+			activity, err := e.App.FindRecordById("activities", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			if activity.GetInt("streak") != e.Record.GetInt("streak") ||
+				activity.GetInt("best_streak") != e.Record.GetInt("best_streak") {
+				return fmt.Errorf("Cannot change activity streaks")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkActivityScoringRequest(e *core.RecordRequestEvent) error {
+	if _, err := scoring.ActivityRules(e.Record); err != nil {
+		return err
+	}
+
+	return e.Next()
+}
+
+func checkActivityScoringHookBind(app core.App) {
+	app.OnRecordCreateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "activities-onCreateRequest_checkScoring",
+		Func: checkActivityScoringRequest,
+	})
+
+	app.OnRecordUpdateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "activities-onUpdateRequest_checkScoring",
+		Func: checkActivityScoringRequest,
+	})
+}
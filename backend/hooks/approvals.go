@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/approvals"
+)
+
+// =============================================================================
+// APPROVALS
+//
+
+func requireRedemptionApprovalHookBind(app core.App) {
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:       "rewards-onUpdateRequest_requireApproval",
+		Priority: 1,
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.HasSuperuserAuth() ||
+				e.Record.GetInt("redeemed") <= e.Record.Original().GetInt("redeemed") {
+				return e.Next()
+			}
+
+			if e.Auth == nil {
+				return apis.NewUnauthorizedError("", nil)
+			}
+
+			if approvals.Required(e.Auth) {
+				return apis.NewBadRequestError(
+					"Redemptions need a supervisor approval, use the redeem action to request one",
+					nil,
+				)
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkUserSupervisionHookBind(app core.App) {
+	app.OnRecordUpdateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "users-onUpdateRequest_checkSupervision",
+		Func: func(e *core.RecordRequestEvent) error {
+			original := e.Record.Original()
+			supervisors := e.Record.GetStringSlice("supervisors")
+
+			if slices.Contains(supervisors, e.Record.Id) {
+				return fmt.Errorf("Users cannot supervise themselves")
+			}
+
+			changed := original.GetBool("requires_approval") != e.Record.GetBool("requires_approval") ||
+				!slices.Equal(original.GetStringSlice("supervisors"), supervisors)
+
+			if changed && !e.HasSuperuserAuth() && approvals.Required(original) {
+				return fmt.Errorf("Supervised users cannot change their own supervision")
+			}
+
+			return e.Next()
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package hooks
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/calendar"
+)
+
+// =============================================================================
+// CALENDAR TOKENS
+//
+
+func generateCalendarTokenHookBind(app core.App) {
+	app.OnRecordCreateRequest(calendar.TokensCollection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "calendar_tokens-onCreateRequest_generateToken",
+		Func: func(e *core.RecordRequestEvent) error {
+			// Left empty to be generated
+			e.Record.Set("token", "")
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest(calendar.TokensCollection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "calendar_tokens-onUpdateRequest_changeToken",
+		Func: func(e *core.RecordRequestEvent) error {
+			original := e.Record.Original()
+			if original.GetString("token") != e.Record.GetString("token") ||
+				original.GetString("user") != e.Record.GetString("user") {
+				return fmt.Errorf("Cannot change the calendar token, create a new one instead")
+			}
+
+			return e.Next()
+		},
+	})
+}
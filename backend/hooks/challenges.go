@@ -0,0 +1,168 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/challenges"
+	"github.com/dr4ghs/orgtool/groups"
+)
+
+// =============================================================================
+// CHALLENGES
+//
+
+func checkChallengeHookBind(app core.App) {
+	app.OnRecordCreateRequest(challenges.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "challenges-onCreateRequest_check",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() {
+				e.Record.Set("owner", e.Auth.Id)
+				e.Record.Set("status", challenges.StatusOpen)
+				e.Record.Set("pot", 0)
+			}
+
+			if err := challenges.Validate(e.Record); err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+
+			group := e.Record.GetString("group")
+			if group != "" && !groups.IsMember(e.App, group, e.Record.GetString("owner")) {
+				return apis.NewBadRequestError(challenges.ErrNotMember.Error(), nil)
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest(challenges.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "challenges-onUpdateRequest_check",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.HasSuperuserAuth() {
+				return e.Next()
+			}
+
+			original := e.Record.Original()
+			for _, field := range []string{"owner", "group", "stake", "pot", "status"} {
+				if original.GetString(field) != e.Record.GetString(field) {
+					return fmt.Errorf("Cannot change the challenge %s", field)
+				}
+			}
+
+			// The rules are fixed once the participants are tracked
+			if !time.Now().Before(original.GetDateTime("start").Time()) {
+				for _, field := range []string{"type", "start", "end", "win_rule"} {
+					if original.GetString(field) != e.Record.GetString(field) {
+						return apis.NewBadRequestError(challenges.ErrStarted.Error(), nil)
+					}
+				}
+			}
+
+			if err := challenges.Validate(e.Record); err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordDeleteRequest(challenges.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "challenges-onDeleteRequest_checkPot",
+		Func: func(e *core.RecordRequestEvent) error {
+			// The stakes would be lost with it
+			if e.Record.GetInt("pot") > 0 {
+				return apis.NewBadRequestError("Cannot delete a challenge holding stakes", nil)
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func joinChallengeHookBind(app core.App) {
+	app.OnRecordCreateRequest(challenges.ParticipantsCollection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "challenge_participants-onCreateRequest_join",
+		Func: func(e *core.RecordRequestEvent) error {
+			e.Record.Set("completed", 0)
+			e.Record.Set("missed", 0)
+			e.Record.Set("winner", false)
+			e.Record.Set("payout", 0)
+
+			// The stake is taken only if the participant is saved
+			return e.App.RunInTransaction(func(txApp core.App) error {
+				e.App = txApp
+
+				if err := challenges.Join(txApp, e.Record, time.Now()); err != nil {
+					return apis.NewBadRequestError(err.Error(), nil)
+				}
+
+				return e.Next()
+			})
+		},
+	})
+}
+
+func checkChallengeTemplateHookBind(app core.App) {
+	app.OnRecordCreateRequest(challenges.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "challenges-onCreateRequest_template",
+		Func: func(e *core.RecordRequestEvent) error {
+			if err := challenges.CheckTemplate(e.App, e.Record); err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest(challenges.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "challenges-onUpdateRequest_template",
+		Func: func(e *core.RecordRequestEvent) error {
+			original := e.Record.Original()
+			if original.GetString("activity") == e.Record.GetString("activity") {
+				return e.Next()
+			}
+
+			if !time.Now().Before(original.GetDateTime("start").Time()) {
+				return apis.NewBadRequestError(challenges.ErrStarted.Error(), nil)
+			}
+
+			// The participants joined with activities following it
+			joined, err := e.App.CountRecords(challenges.ParticipantsCollection, dbx.HashExp{"challenge": e.Record.Id})
+			if err != nil {
+				return err
+			}
+			if joined > 0 {
+				return apis.NewBadRequestError("Cannot change the template of a joined challenge", nil)
+			}
+
+			if err := challenges.CheckTemplate(e.App, e.Record); err != nil {
+				return apis.NewBadRequestError(err.Error(), nil)
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func leaveChallengeHookBind(app core.App) {
+	app.OnRecordDeleteRequest(challenges.ParticipantsCollection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "challenge_participants-onDeleteRequest_leave",
+		Func: func(e *core.RecordRequestEvent) error {
+			// The stake is given back only if the participant is deleted
+			return e.App.RunInTransaction(func(txApp core.App) error {
+				e.App = txApp
+
+				if err := challenges.Leave(txApp, e.Record, time.Now()); err != nil {
+					return apis.NewBadRequestError(err.Error(), nil)
+				}
+
+				return e.Next()
+			})
+		},
+	})
+}
@@ -0,0 +1,267 @@
+package hooks
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/period"
+)
+
+// =============================================================================
+// ENTRIES
+//
+
+func checkClosedEntryOnUpdateHookBind(app core.App) {
+	for _, typ := range period.Types {
+		app.OnRecordUpdateRequest(fmt.Sprintf("%s_entries", typ)).
+			Unbind(fmt.Sprintf("%s_entries-onUpdateRequest_closed", typ))
+	}
+
+	app.OnRecordUpdateRequest(entries.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "entries-onUpdateRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			rec, err := e.App.FindRecordById(entries.Collection, e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			if rec.GetBool("closed") {
+				return fmt.Errorf("Is not possible to reopen a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkClosedEntryOnDeleteHookBind(app core.App) {
+	for _, typ := range period.Types {
+		app.OnRecordDeleteRequest(fmt.Sprintf("%s_entries", typ)).
+			Unbind(fmt.Sprintf("%s_entries-onDeleteRequest_closed", typ))
+	}
+
+	app.OnRecordDeleteRequest(entries.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "entries-onDeleteRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.GetBool("closed") {
+				return fmt.Errorf("Is not possible to delete a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// Fields the closing job trusts, set by the server only
+var entryServerFields = []string{
+	"activity",
+	"period_type",
+	"period_start",
+	"period_end",
+	"goal",
+	"closed",
+	"missed",
+	"pending_approval",
+}
+
+func protectEntryFieldsHook(fields []string) *hook.Handler[*core.RecordRequestEvent] {
+	return &hook.Handler[*core.RecordRequestEvent]{
+		Id: "entries-onUpdateRequest_protectFields",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.HasSuperuserAuth() {
+				return e.Next()
+			}
+
+			original := e.Record.Original()
+			for _, field := range fields {
+				if original.GetString(field) != e.Record.GetString(field) {
+					return fmt.Errorf("Cannot change the entry %s", field)
+				}
+			}
+
+			return e.Next()
+		},
+	}
+}
+
+func protectEntryFieldsHookBind(app core.App) {
+	app.OnRecordUpdateRequest(entries.Collection).Bind(protectEntryFieldsHook(entryServerFields))
+}
+
+// Progress goes through the increment endpoint only
+func protectEntryProgressHookBind(app core.App) {
+	app.OnRecordUpdateRequest(entries.Collection).Bind(
+		protectEntryFieldsHook(append(slices.Clone(entryServerFields), "progress")),
+	)
+}
+
+// =============================================================================
+// DAILY ENTRIES
+//
+
+func createDailyEntriesHooks(app core.App) {
+	app.OnRecordUpdateRequest("daily_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "daily_entries_onUpdate",
+		Func: func(e *core.RecordRequestEvent) error {
+			rec, _ := e.App.FindRecordById("daily_entries", e.Record.Id)
+			if rec.GetBool("closed") {
+				return fmt.Errorf("Is not possible to reopen a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordDeleteRequest("daily_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "daily_entries_onDelete",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.GetBool("closed") {
+				return fmt.Errorf("Is not possible to delete a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkClosedDailyEntryOnUpdateHookBind(app core.App) {
+	app.OnRecordUpdateRequest("daily_entries").Unbind("daily_entries_onUpdate")
+	app.OnRecordUpdateRequest("daily_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "daily_entries-onUpdateRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			rec, err := e.App.FindRecordById("daily_entries", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			if rec.GetBool("closed") {
+				return fmt.Errorf("Is not possible to reopen a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkClosedDailyEntryOnDeleteHookBind(app core.App) {
+	app.OnRecordDeleteRequest("daily_entries").Unbind("daily_entries_onDelete")
+	app.OnRecordDeleteRequest("daily_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "daily_entries-onDeleteRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.GetBool("closed") {
+				return fmt.Errorf("Is not possible to delete a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// =============================================================================
+// WEEKLY ENTRIES
+//
+
+func checkClosedWeeklyEntryOnUpdateHookBind(app core.App) {
+	app.OnRecordUpdateRequest("weekly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "weekly_entries-onUpdateRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			rec, err := e.App.FindRecordById("weekly_entries", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			if rec.GetBool("closed") {
+				return fmt.Errorf("Is not possible to reopen a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkClosedWeeklyEntryOnDeleteHookBind(app core.App) {
+	app.OnRecordDeleteRequest("weekly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "weekly_entries-onDeleteRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.GetBool("closed") {
+				return fmt.Errorf("Is not possible to delete a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// =============================================================================
+// MONTHLY ENTRIES
+//
+
+func checkClosedMonthlyEntryOnUpdateHookBind(app core.App) {
+	app.OnRecordUpdateRequest("monthly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "monthly_entries-onUpdateRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			rec, err := e.App.FindRecordById("monthly_entries", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			if rec.GetBool("closed") {
+				return fmt.Errorf("Is not possible to reopen a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkClosedMonthlyEntryOnDeleteHookBind(app core.App) {
+	app.OnRecordDeleteRequest("monthly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "monthly_entries-onDeleteRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.GetBool("closed") {
+				return fmt.Errorf("Is not possible to delete a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// =============================================================================
+// YEARLY ENTRIES
+//
+
+func checkClosedYearlyEntryOnUpdateHookBind(app core.App) {
+	app.OnRecordUpdateRequest("yearly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "yearly_entries-onUpdateRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			rec, err := e.App.FindRecordById("yearly_entries", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			if rec.GetBool("closed") {
+				return fmt.Errorf("Is not possible to reopen a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkClosedYearlyEntryOnDeleteHookBind(app core.App) {
+	app.OnRecordDeleteRequest("yearly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "yearly_entries-onDeleteRequest_closed",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.GetBool("closed") {
+				return fmt.Errorf("Is not possible to delete a closed entry")
+			}
+
+			return e.Next()
+		},
+	})
+}
@@ -0,0 +1,196 @@
+package hooks
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/entries"
+	"github.com/dr4ghs/orgtool/groups"
+)
+
+// =============================================================================
+// GROUPS
+//
+
+func createGroupOwnerHookBind(app core.App) {
+	app.OnRecordCreateRequest(groups.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "groups-onCreateRequest_injectOwner",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() {
+				if e.Auth == nil {
+					return apis.NewUnauthorizedError("", nil)
+				}
+
+				e.Record.Set("owner", e.Auth.Id)
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordAfterCreateSuccess(groups.Collection).Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "groups-onCreateSuccess_addOwner",
+		Func: func(e *core.RecordEvent) error {
+			_, err := groups.AddMember(e.App, e.Record.Id, e.Record.GetString("owner"), groups.RoleOwner)
+			if err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest(groups.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "groups-onUpdateRequest_changeOwner",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.Original().GetString("owner") != e.Record.GetString("owner") {
+				return fmt.Errorf("Cannot change group owner")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkGroupMemberRoleHookBind(app core.App) {
+	check := func(e *core.RecordRequestEvent) error {
+		// There is only one owner, the one of the group
+		if e.Record.GetString("role") == groups.RoleOwner ||
+			e.Record.Original().GetString("role") == groups.RoleOwner {
+			return fmt.Errorf("Cannot change the group owner membership")
+		}
+
+		return e.Next()
+	}
+
+	app.OnRecordCreateRequest(groups.MembersCollection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "group_members-onCreateRequest_checkRole",
+		Func: check,
+	})
+
+	app.OnRecordUpdateRequest(groups.MembersCollection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "group_members-onUpdateRequest_checkRole",
+		Func: check,
+	})
+
+	app.OnRecordDeleteRequest(groups.MembersCollection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "group_members-onDeleteRequest_checkRole",
+		Func: check,
+	})
+}
+
+// =============================================================================
+// SHARED ITEMS
+//
+
+// checkGroupManagerRequest lets the members of a group only read the items
+// shared with it: changing them is up to their owner and the group managers.
+func checkGroupManagerRequest(e *core.RecordRequestEvent) error {
+	if e.HasSuperuserAuth() {
+		return e.Next()
+	}
+
+	if e.Auth == nil {
+		return apis.NewUnauthorizedError("", nil)
+	}
+
+	// Items are only added to groups the user manages
+	if group := e.Record.GetString("group"); group != "" && !groups.CanManage(e.App, group, e.Auth.Id) {
+		if e.Record.IsNew() || e.Record.Original().GetString("group") != group {
+			return fmt.Errorf("Only group owners and admins can share items with the group")
+		}
+	}
+
+	if e.Record.IsNew() || e.Record.Original().GetString("user") == e.Auth.Id {
+		return e.Next()
+	}
+
+	if !groups.CanManage(e.App, e.Record.Original().GetString("group"), e.Auth.Id) {
+		return fmt.Errorf("Only group owners and admins can change the group items")
+	}
+
+	return e.Next()
+}
+
+// Fields the group members change by using the shared items
+var groupUsageFields = map[string][]string{
+	"rewards": {"redeemed", "used"},
+}
+
+// checkGroupManagerUpdateRequest lets the members of a group use the shared
+// items, changing nothing but their usage fields.
+func checkGroupManagerUpdateRequest(e *core.RecordRequestEvent) error {
+	original := e.Record.Original()
+
+	if !e.HasSuperuserAuth() && e.Auth != nil && groups.IsMember(e.App, original.GetString("group"), e.Auth.Id) {
+		usage := groupUsageFields[e.Record.Collection().Name]
+
+		used := true
+		for _, field := range e.Record.Collection().Fields.FieldNames() {
+			if field == "updated" || slices.Contains(usage, field) {
+				continue
+			}
+
+			if fmt.Sprint(original.Get(field)) != fmt.Sprint(e.Record.Get(field)) {
+				used = false
+				break
+			}
+		}
+
+		if used {
+			return e.Next()
+		}
+	}
+
+	return checkGroupManagerRequest(e)
+}
+
+func checkGroupManagerHookBind(app core.App) {
+	for _, collection := range []string{"activities", "rewards"} {
+		app.OnRecordCreateRequest(collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+			Id:   fmt.Sprintf("%s-onCreateRequest_checkGroup", collection),
+			Func: checkGroupManagerRequest,
+		})
+
+		app.OnRecordUpdateRequest(collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+			Id:   fmt.Sprintf("%s-onUpdateRequest_checkGroup", collection),
+			Func: checkGroupManagerUpdateRequest,
+		})
+
+		app.OnRecordDeleteRequest(collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+			Id:   fmt.Sprintf("%s-onDeleteRequest_checkGroup", collection),
+			Func: checkGroupManagerRequest,
+		})
+	}
+}
+
+func setEntryCompletedByHookBind(app core.App) {
+	app.OnRecordUpdateRequest(entries.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "entries-onUpdateRequest_completedBy",
+		Func: func(e *core.RecordRequestEvent) error {
+			// Set by the server only, whatever the client sent
+			if !e.HasSuperuserAuth() {
+				e.Record.Set("completed_by", e.Record.Original().GetString("completed_by"))
+			}
+
+			progress := e.Record.GetInt("progress")
+
+			switch {
+			case entries.Completes(e.Record.Original(), progress) && !e.HasSuperuserAuth():
+				if e.Auth == nil {
+					return apis.NewUnauthorizedError("", nil)
+				}
+
+				e.Record.Set("completed_by", e.Auth.Id)
+			case progress < e.Record.GetInt("goal"):
+				e.Record.Set("completed_by", "")
+			}
+
+			return e.Next()
+		},
+	})
+}
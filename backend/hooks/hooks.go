@@ -0,0 +1,139 @@
+package hooks
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/migrations"
+)
+
+// Hook binders introduced by each migration. They are applied in the same
+// order the migrations were applied, so a later version can unbind or replace
+// the handlers of the previous one by reusing its ids.
+var versions = map[string][]func(core.App){
+	"1751529958_create_database.go": {
+		createRewardsHooks,
+		createActivitiesHooks,
+		createDailyEntriesHooks,
+	},
+	"1751618410_activities.go": {
+		// Activities
+		injectActivityUserHookBind,
+		createActivityEntryHookBind,
+		preventActivityOwnerChangeHookBind,
+		changeActivityTypeHookBind,
+
+		// Daily entries
+		checkClosedDailyEntryOnUpdateHookBind,
+		checkClosedDailyEntryOnDeleteHookBind,
+
+		// Weekly entries
+		checkClosedWeeklyEntryOnUpdateHookBind,
+		checkClosedWeeklyEntryOnDeleteHookBind,
+
+		// Monthly entries
+		checkClosedMonthlyEntryOnUpdateHookBind,
+		checkClosedMonthlyEntryOnDeleteHookBind,
+
+		// Yearly entries
+		checkClosedYearlyEntryOnUpdateHookBind,
+		checkClosedYearlyEntryOnDeleteHookBind,
+	},
+	"1751790000_users_timezone.go": {
+		checkUserClockHookBind,
+	},
+	"1751800000_point_transactions.go": {
+		redeemRewardLedgerHookBind,
+		protectUserPointsHookBind,
+	},
+	"1751810000_period_watermarks.go": {
+		createActivityPeriodEntryHookBind,
+	},
+	"1751820000_activities_schedule.go": {
+		checkActivityScheduleHookBind,
+	},
+	"1751830000_entries.go": {
+		openActivityEntryHookBind,
+		changeEntryTypeHookBind,
+		checkClosedEntryOnUpdateHookBind,
+		checkClosedEntryOnDeleteHookBind,
+		protectEntryFieldsHookBind,
+	},
+	"1751840000_progress_events.go": {
+		protectEntryProgressHookBind,
+	},
+	"1751850000_streaks.go": {
+		preventActivityStreakChangeHookBind,
+	},
+	"1751860000_penalties.go": {
+		protectUserPointsFloorHookBind,
+	},
+	"1751870000_scoring.go": {
+		checkActivityScoringHookBind,
+	},
+	"1751880000_rewards_reset.go": {
+		checkRewardResetHookBind,
+	},
+	"1751890000_redemptions.go": {
+		redeemRewardHookBind,
+		useRewardHookBind,
+		rewardUpdateTransactionHookBind,
+	},
+	"1751900000_refunds.go": {
+		defaultRewardRefundWindowHookBind,
+	},
+	"1751910000_savings.go": {
+		preventRewardSavedChangeHookBind,
+		releaseRewardSavedHookBind,
+		enrichUserAvailablePointsHookBind,
+	},
+	"1751920000_reward_limits.go": {
+		enrichRewardNextAvailableHookBind,
+	},
+	"1751930000_groups.go": {
+		createGroupOwnerHookBind,
+		checkGroupMemberRoleHookBind,
+		checkGroupManagerHookBind,
+		setEntryCompletedByHookBind,
+	},
+	"1751940000_approvals.go": {
+		requireRedemptionApprovalHookBind,
+		checkUserSupervisionHookBind,
+	},
+	"1751950000_leaderboards.go": {
+		refreshUserLeaderboardsHookBind,
+	},
+	"1751960000_challenges.go": {
+		checkChallengeHookBind,
+		joinChallengeHookBind,
+	},
+	"1751970000_partnerships.go": {
+		checkPartnershipHookBind,
+	},
+	"1751980000_calendar_tokens.go": {
+		generateCalendarTokenHookBind,
+	},
+	"1751990000_webhooks.go": {
+		injectWebhookUserHookBind,
+		checkWebhookURLHookBind,
+		emitWebhookEventsHookBind,
+	},
+	"1752010000_challenge_templates.go": {
+		checkChallengeTemplateHookBind,
+		leaveChallengeHookBind,
+	},
+}
+
+func Bind(app core.App) error {
+	files, err := migrations.Applied(app)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		for _, bind := range versions[file] {
+			bind(app)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package hooks
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// =============================================================================
+// PARTNERSHIPS
+//
+
+func checkPartnershipHookBind(app core.App) {
+	check := func(e *core.RecordRequestEvent) error {
+		owner := e.Record.GetString("owner")
+		if e.Record.GetString("partner") == owner {
+			return fmt.Errorf("Cannot be your own partner")
+		}
+
+		// Only the owner's activities can be shared
+		for _, id := range e.Record.GetStringSlice("activities") {
+			activity, err := e.App.FindRecordById("activities", id)
+			if err != nil || activity.GetString("user") != owner {
+				return fmt.Errorf("Cannot share activity '%s'", id)
+			}
+		}
+
+		return e.Next()
+	}
+
+	app.OnRecordCreateRequest("partnerships").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "partnerships-onCreateRequest_check",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() {
+				e.Record.Set("owner", e.Auth.Id)
+			}
+
+			return check(e)
+		},
+	})
+
+	app.OnRecordUpdateRequest("partnerships").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "partnerships-onUpdateRequest_check",
+		Func: func(e *core.RecordRequestEvent) error {
+			original := e.Record.Original()
+			if original.GetString("owner") != e.Record.GetString("owner") ||
+				original.GetString("partner") != e.Record.GetString("partner") {
+				return fmt.Errorf("Cannot change the partnership users")
+			}
+
+			return check(e)
+		},
+	})
+}
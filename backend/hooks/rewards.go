@@ -0,0 +1,364 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+// =============================================================================
+// REWARDS
+//
+
+func createRewardsHooks(app core.App) {
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:       "rewards-OnUpdateRequest_checkRequest",
+		Priority: 0,
+		Func: func(e *core.RecordRequestEvent) error {
+			reward, err := e.App.FindRecordById("rewards", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			// Redeem
+			redeemed := e.Record.GetInt("redeemed") - reward.GetInt("redeemed")
+			if redeemed < 0 {
+				return apis.NewBadRequestError(
+					"Cannot update redeemed rewards: new value is smaller than the old one",
+					nil,
+				)
+			}
+
+			if e.Record.GetInt("redeemed") > reward.GetInt("max_redeemables") {
+				return apis.NewBadRequestError(rewards.ErrMaxRedeemables.Error(), nil)
+			}
+
+			// Use
+			used := e.Record.GetInt("used") - reward.GetInt("used")
+			if used < 0 {
+				return apis.NewBadRequestError(fmt.Sprintf("Cannot use %d rewards", used), nil)
+			}
+
+			if e.Record.GetInt("used") > reward.GetInt("redeemed") {
+				return apis.NewBadRequestError(rewards.ErrAllUsed.Error(), nil)
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:       "rewards-OnUpdateRequest_redeem",
+		Priority: 2,
+		Func: func(e *core.RecordRequestEvent) error {
+			reward, err := e.App.FindRecordById("rewards", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			user, err := e.App.FindRecordById("users", reward.GetString("user"))
+			if err != nil {
+				return err
+			}
+
+			redeemed := e.Record.GetInt("redeemed") - reward.GetInt("redeemed")
+			cost := redeemed * reward.GetInt("unit_cost")
+			if user.GetInt("points") < cost {
+				return fmt.Errorf("Not enough points to redeem reward")
+			}
+
+			user.Set("points", user.GetInt("points")-cost)
+
+			if err := e.App.Save(user); err != nil {
+				e.App.Logger().Error(err.Error())
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func redeemRewardLedgerHookBind(app core.App) {
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:       "rewards-OnUpdateRequest_redeem",
+		Priority: 2,
+		Func: func(e *core.RecordRequestEvent) error {
+			reward, err := e.App.FindRecordById("rewards", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			redeemed := e.Record.GetInt("redeemed") - reward.GetInt("redeemed")
+			if redeemed == 0 {
+				return e.Next()
+			}
+
+			user, err := e.App.FindRecordById("users", reward.GetString("user"))
+			if err != nil {
+				return err
+			}
+
+			cost := redeemed * reward.GetInt("unit_cost")
+			if user.GetInt("points") < cost {
+				return fmt.Errorf("Not enough points to redeem reward")
+			}
+
+			if _, err := ledger.Record(e.App, user, -cost, ledger.ReasonRedemption, reward); err != nil {
+				e.App.Logger().Error(err.Error())
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkRewardResetRequest(e *core.RecordRequestEvent) error {
+	if e.Record.GetString("reset_period") == rewards.ResetCustom {
+		if _, err := rewards.ResetRule(e.Record); err != nil {
+			return err
+		}
+	}
+
+	// The current window is already clean for new rewards
+	if e.Record.IsNew() {
+		e.Record.Set("last_reset", types.NowDateTime())
+	}
+
+	return e.Next()
+}
+
+func checkRewardResetHookBind(app core.App) {
+	app.OnRecordCreateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "rewards-onCreateRequest_checkReset",
+		Func: checkRewardResetRequest,
+	})
+
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "rewards-onUpdateRequest_checkReset",
+		Func: checkRewardResetRequest,
+	})
+}
+
+// New rewards get the default refund window unless the request sets one
+func defaultRewardRefundWindowHookBind(app core.App) {
+	app.OnRecordCreateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "rewards-onCreateRequest_refundWindow",
+		Func: func(e *core.RecordRequestEvent) error {
+			info, err := e.RequestInfo()
+			if err != nil {
+				return err
+			}
+
+			if _, ok := info.Body["refund_window"]; !ok {
+				e.Record.Set("refund_window", rewards.DefaultRefundWindow)
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// Charges the redeemed units to the user redeeming them, the reward owner for
+// superusers, along with the reward limits, savings and history
+func redeemRewardHookBind(app core.App) {
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:       "rewards-OnUpdateRequest_redeem",
+		Priority: 2,
+		Func: func(e *core.RecordRequestEvent) error {
+			redeemed := e.Record.GetInt("redeemed") - e.Record.Original().GetInt("redeemed")
+			if redeemed == 0 {
+				return e.Next()
+			}
+
+			userId := e.Record.GetString("user")
+			if !e.HasSuperuserAuth() {
+				if e.Auth == nil {
+					return apis.NewUnauthorizedError("", nil)
+				}
+
+				userId = e.Auth.Id
+			}
+
+			user, err := e.App.FindRecordById("users", userId)
+			if err != nil {
+				return err
+			}
+
+			_, err = rewards.Charge(e.App, e.Record, user, redeemed)
+			switch {
+			case errors.Is(err, rewards.ErrLimit), errors.Is(err, rewards.ErrNotEnoughPoints):
+				return apis.NewBadRequestError(err.Error(), nil)
+			case err != nil:
+				e.App.Logger().Error(err.Error())
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func useRewardHookBind(app core.App) {
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:       "rewards-OnUpdateRequest_use",
+		Priority: 2,
+		Func: func(e *core.RecordRequestEvent) error {
+			reward, err := e.App.FindRecordById("rewards", e.Record.Id)
+			if err != nil {
+				return err
+			}
+
+			used := e.Record.GetInt("used") - reward.GetInt("used")
+			if used <= 0 {
+				return e.Next()
+			}
+
+			if err := rewards.UseRedemptions(e.App, reward, used, time.Now()); err != nil {
+				e.App.Logger().Error(err.Error())
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+// The update request hooks debit points and record redemptions before the
+// reward itself is saved, run them all in the same transaction.
+func rewardUpdateTransactionHookBind(app core.App) {
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:       "rewards-OnUpdateRequest_transaction",
+		Priority: -1,
+		Func: func(e *core.RecordRequestEvent) error {
+			return e.App.RunInTransaction(func(txApp core.App) error {
+				e.App = txApp
+				return e.Next()
+			})
+		},
+	})
+}
+
+func preventRewardSavedChangeHookBind(app core.App) {
+	app.OnRecordCreateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "rewards-onCreateRequest_changeSaved",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() && e.Record.GetInt("saved") != 0 {
+				return rewards.ErrSavedNotAllowed
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest("rewards").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "rewards-onUpdateRequest_changeSaved",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() && e.Record.Original().GetInt("saved") != e.Record.GetInt("saved") {
+				return rewards.ErrSavedNotAllowed
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "users-onUpdateRequest_changeLockedPoints",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() &&
+				e.Record.Original().GetInt("locked_points") != e.Record.GetInt("locked_points") {
+				return rewards.ErrSavedNotAllowed
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func releaseRewardSavedHookBind(app core.App) {
+	app.OnRecordAfterDeleteSuccess("rewards").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "rewards-onDeleteSuccess_releaseSaved",
+		Func: func(e *core.RecordEvent) error {
+			saved := e.Record.GetInt("saved")
+			if saved == 0 {
+				return e.Next()
+			}
+
+			// Gone along with its rewards
+			user, err := e.App.FindRecordById("users", e.Record.GetString("user"))
+			if err != nil {
+				return e.Next()
+			}
+
+			user.Set("locked_points", max(user.GetInt("locked_points")-saved, 0))
+			if err := e.App.Save(user); err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func enrichRewardNextAvailableHookBind(app core.App) {
+	// Lists compute the whole page at once, the enrich handler skips them
+	app.OnRecordsListRequest("rewards").Bind(&hook.Handler[*core.RecordsListRequestEvent]{
+		Id: "rewards-onListRequest_nextAvailable",
+		Func: func(e *core.RecordsListRequestEvent) error {
+			next, err := rewards.NextAvailableAll(e.App, e.Records, time.Now())
+			if err != nil {
+				return err
+			}
+
+			for _, reward := range e.Records {
+				setRewardNextAvailable(reward, next[reward.Id])
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordEnrich("rewards").Bind(&hook.Handler[*core.RecordEnrichEvent]{
+		Id: "rewards-onEnrich_nextAvailable",
+		Func: func(e *core.RecordEnrichEvent) error {
+			if e.Record.Get("next_available_at") != nil {
+				return e.Next()
+			}
+
+			user, err := e.App.FindRecordById("users", e.Record.GetString("user"))
+			if err != nil {
+				return err
+			}
+
+			next, err := rewards.NextAvailable(e.App, period.UserClock(user), e.Record, time.Now())
+			if err != nil {
+				return err
+			}
+
+			setRewardNextAvailable(e.Record, next)
+
+			return e.Next()
+		},
+	})
+}
+
+// Left empty once the stock ran out
+func setRewardNextAvailable(reward *core.Record, next time.Time) {
+	nextAvailable := ""
+	if !next.IsZero() {
+		nextAvailable = next.UTC().Format(types.DefaultDateLayout)
+	}
+
+	reward.WithCustomData(true)
+	reward.Set("next_available_at", nextAvailable)
+}
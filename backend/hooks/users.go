@@ -0,0 +1,141 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/leaderboard"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/rewards"
+)
+
+// =============================================================================
+// USERS
+//
+
+func checkUserClockRequest(e *core.RecordRequestEvent) error {
+	if _, err := period.LoadLocation(e.Record.GetString("timezone")); err != nil {
+		return fmt.Errorf("Unknown timezone '%s'", e.Record.GetString("timezone"))
+	}
+
+	if _, err := period.ParseDayStart(e.Record.GetString("day_start")); err != nil {
+		return err
+	}
+
+	return e.Next()
+}
+
+func checkUserClockHookBind(app core.App) {
+	app.OnRecordCreateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "users-onCreateRequest_checkClock",
+		Func: checkUserClockRequest,
+	})
+
+	app.OnRecordUpdateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "users-onUpdateRequest_checkClock",
+		Func: checkUserClockRequest,
+	})
+}
+
+// Points only change through the ledger. Superusers editing them get the
+// difference recorded as an adjustment, so the nightly reconcile keeps it.
+func protectUserPointsHookBind(app core.App) {
+	app.OnRecordCreateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "users-onCreateRequest_protectPoints",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.GetInt("points") != 0 {
+				return fmt.Errorf("Points can only be changed through the ledger")
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "users-onUpdateRequest_protectPoints",
+		Func: func(e *core.RecordRequestEvent) error {
+			original := e.Record.Original().GetInt("points")
+			delta := e.Record.GetInt("points") - original
+			if delta == 0 {
+				return e.Next()
+			}
+
+			if !e.HasSuperuserAuth() {
+				return fmt.Errorf("Points can only be changed through the ledger")
+			}
+
+			return e.App.RunInTransaction(func(txApp core.App) error {
+				e.App = txApp
+
+				e.Record.Set("points", original)
+				if _, err := ledger.Record(txApp, e.Record, delta, ledger.ReasonAdjustment, nil); err != nil {
+					return err
+				}
+
+				return e.Next()
+			})
+		},
+	})
+}
+
+// The floor protects points from penalties, so only superusers can move it
+func protectUserPointsFloorHookBind(app core.App) {
+	app.OnRecordCreateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "users-onCreateRequest_protectPointsFloor",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() && e.Record.GetInt("points_floor") != 0 {
+				return fmt.Errorf("Cannot set the points floor")
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest("users").Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "users-onUpdateRequest_protectPointsFloor",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.HasSuperuserAuth() {
+				return e.Next()
+			}
+
+			if e.Record.Original().GetInt("points_floor") != e.Record.GetInt("points_floor") {
+				return fmt.Errorf("Cannot change the points floor")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func enrichUserAvailablePointsHookBind(app core.App) {
+	app.OnRecordEnrich("users").Bind(&hook.Handler[*core.RecordEnrichEvent]{
+		Id: "users-onEnrich_availablePoints",
+		Func: func(e *core.RecordEnrichEvent) error {
+			e.Record.WithCustomData(true)
+			e.Record.Set("available_points", rewards.Available(e.Record))
+
+			return e.Next()
+		},
+	})
+}
+
+func refreshUserLeaderboardsHookBind(app core.App) {
+	app.OnRecordAfterUpdateSuccess("users").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "users-onUpdateSuccess_refreshLeaderboards",
+		Func: func(e *core.RecordEvent) error {
+			if e.Record.Original().GetBool("leaderboard_opt_out") == e.Record.GetBool("leaderboard_opt_out") {
+				return e.Next()
+			}
+
+			if err := leaderboard.RefreshUsers(e.App, []string{e.Record.Id}, time.Now()); err != nil {
+				return err
+			}
+
+			return e.Next()
+		},
+	})
+}
@@ -0,0 +1,129 @@
+package hooks
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/rewards"
+	"github.com/dr4ghs/orgtool/webhooks"
+)
+
+// =============================================================================
+// WEBHOOKS
+//
+
+func injectWebhookUserHookBind(app core.App) {
+	app.OnRecordCreateRequest(webhooks.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "webhooks-onCreateRequest_injectUser",
+		Func: func(e *core.RecordRequestEvent) error {
+			if !e.HasSuperuserAuth() {
+				e.Record.Set("user", e.Auth.Id)
+			}
+
+			return e.Next()
+		},
+	})
+
+	app.OnRecordUpdateRequest(webhooks.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id: "webhooks-onUpdateRequest_changeUser",
+		Func: func(e *core.RecordRequestEvent) error {
+			if e.Record.Original().GetString("user") != e.Record.GetString("user") {
+				return fmt.Errorf("Cannot change webhook user")
+			}
+
+			return e.Next()
+		},
+	})
+}
+
+func checkWebhookURLRequest(e *core.RecordRequestEvent) error {
+	if err := webhooks.CheckURL(e.Record.GetString("url")); err != nil {
+		return apis.NewBadRequestError(err.Error(), nil)
+	}
+
+	return e.Next()
+}
+
+func checkWebhookURLHookBind(app core.App) {
+	app.OnRecordCreateRequest(webhooks.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "webhooks-onCreateRequest_checkUrl",
+		Func: checkWebhookURLRequest,
+	})
+
+	app.OnRecordUpdateRequest(webhooks.Collection).Bind(&hook.Handler[*core.RecordRequestEvent]{
+		Id:   "webhooks-onUpdateRequest_checkUrl",
+		Func: checkWebhookURLRequest,
+	})
+}
+
+// Events are queued by the model hooks so they are sent whatever path saved
+// the record, and only if its transaction commits.
+func emitWebhookEventsHookBind(app core.App) {
+	app.OnRecordUpdate("entries").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "entries-onUpdate_emitCompleted",
+		Func: func(e *core.RecordEvent) error {
+			goal := e.Record.GetInt("goal")
+			completed := e.Record.Original().GetInt("progress") < goal && e.Record.GetInt("progress") >= goal
+
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			if !completed {
+				return nil
+			}
+
+			activity, err := e.App.FindRecordById("activities", e.Record.GetString("activity"))
+			if err != nil {
+				return err
+			}
+
+			return webhooks.Emit(
+				e.App,
+				activity.GetString("user"),
+				webhooks.EventEntryCompleted,
+				map[string]any{"entry": e.Record, "activity": activity},
+			)
+		},
+	})
+
+	app.OnRecordCreate("point_transactions").Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "point_transactions-onCreate_emitAwarded",
+		Func: func(e *core.RecordEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			if e.Record.GetString("reason") != ledger.ReasonAward {
+				return nil
+			}
+
+			return webhooks.Emit(
+				e.App,
+				e.Record.GetString("user"),
+				webhooks.EventPointsAwarded,
+				map[string]any{"transaction": e.Record},
+			)
+		},
+	})
+
+	app.OnRecordCreate(rewards.RedemptionsCollection).Bind(&hook.Handler[*core.RecordEvent]{
+		Id: "redemptions-onCreate_emitRedeemed",
+		Func: func(e *core.RecordEvent) error {
+			if err := e.Next(); err != nil {
+				return err
+			}
+
+			return webhooks.Emit(
+				e.App,
+				e.Record.GetString("user"),
+				webhooks.EventRewardRedeemed,
+				map[string]any{"redemption": e.Record},
+			)
+		},
+	})
+}
@@ -0,0 +1,111 @@
+// Package testutil holds the fixtures shared by the tests of the other
+// packages.
+package testutil
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	"github.com/dr4ghs/orgtool/hooks"
+	"github.com/dr4ghs/orgtool/ledger"
+)
+
+// migrated is a data dir with every migration applied, created once per test
+// binary and left for the system to clean: copying it is much faster than
+// migrating each app.
+var migrated = sync.OnceValues(func() (string, error) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		return "", err
+	}
+
+	// Closes the database so the copies have everything written
+	app.ResetBootstrapState()
+
+	return app.DataDir(), nil
+})
+
+// NewApp returns a test app with the domain hooks bound the way main binds
+// them on every boot, on a copy of dataDir when given.
+func NewApp(t testing.TB, dataDir ...string) *tests.TestApp {
+	if len(dataDir) == 0 {
+		dir, err := migrated()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dataDir = []string{dir}
+	}
+
+	app, err := tests.NewTestApp(dataDir...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hooks.Bind(app); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+// NewRecord saves a record of the collection with the given fields, its id
+// generated when empty.
+func NewRecord(t testing.TB, app core.App, collection string, id string, fields map[string]any) *core.Record {
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := core.NewRecord(c)
+	if id != "" {
+		record.Id = id
+	}
+	record.Load(fields)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+
+	return record
+}
+
+// NewUser saves a user with points awarded through the ledger. Users with a
+// fixed id get a token key derived from it, so their tokens are valid in every
+// app the same fixture is created in.
+func NewUser(t testing.TB, app core.App, id string, email string, points int) *core.Record {
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	user.SetPassword("1234567890")
+	if id != "" {
+		user.Id = id
+		user.SetTokenKey(id + "-token-key-0123456789")
+	}
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if points != 0 {
+		if _, err := ledger.Record(app, user, points, ledger.ReasonAward, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return user
+}
+
+// Reload returns the record as it is stored now.
+func Reload(t testing.TB, app core.App, record *core.Record) *core.Record {
+	fresh, err := app.FindRecordById(record.Collection().Name, record.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fresh
+}
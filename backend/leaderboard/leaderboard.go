@@ -0,0 +1,199 @@
+package leaderboard
+
+import (
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/groups"
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+)
+
+// Cache of the group scores, rebuilt by Refresh
+const Collection = "leaderboard_scores"
+
+const (
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowAll   = "all"
+)
+
+var Windows = []string{WindowWeek, WindowMonth, WindowAll}
+
+// Ledger reasons counted as earned points, spending them doesn't lower a score
+var Reasons = []string{ledger.ReasonAward}
+
+type Score struct {
+	User   string `db:"user" json:"user"`
+	Points int    `db:"points" json:"points"`
+	Rank   int    `db:"-" json:"rank"`
+}
+
+// WindowStart returns when the window containing now began, zero for all time.
+// Weeks and months follow the group owner's clock.
+func WindowStart(clock period.Clock, window string, now time.Time) (time.Time, error) {
+	var typ string
+	switch window {
+	case WindowWeek:
+		typ = period.Weekly
+	case WindowMonth:
+		typ = period.Monthly
+	default:
+		return time.Time{}, nil
+	}
+
+	start, _, err := clock.Bounds(typ, now)
+
+	return start, err
+}
+
+// Compute ranks the members of the group that didn't opt out by the points
+// they earned since start. Members with the same points share the rank.
+func Compute(app core.App, groupId string, start time.Time) ([]Score, error) {
+	var members []struct {
+		User string `db:"user"`
+	}
+
+	err := app.DB().
+		Select("[[group_members.user]] AS [[user]]").
+		From(groups.MembersCollection).
+		InnerJoin("users", dbx.NewExp("users.id = group_members.user")).
+		Where(dbx.HashExp{"group_members.group": groupId, "users.leaderboard_opt_out": false}).
+		All(&members)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]Score, 0, len(members))
+	for _, member := range members {
+		var score Score
+
+		query := app.DB().
+			Select("COALESCE(SUM(delta), 0) AS points").
+			From("point_transactions").
+			Where(dbx.HashExp{"user": member.User}).
+			AndWhere(dbx.In("reason", toAny(Reasons)...))
+		if !start.IsZero() {
+			query.AndWhere(dbx.NewExp(
+				"created >= {:start}",
+				dbx.Params{"start": start.UTC().Format(types.DefaultDateLayout)},
+			))
+		}
+
+		if err := query.One(&score); err != nil {
+			return nil, err
+		}
+		score.User = member.User
+
+		scores = append(scores, score)
+	}
+
+	slices.SortStableFunc(scores, func(a Score, b Score) int {
+		return b.Points - a.Points
+	})
+
+	for i := range scores {
+		scores[i].Rank = i + 1
+		if i > 0 && scores[i].Points == scores[i-1].Points {
+			scores[i].Rank = scores[i-1].Rank
+		}
+	}
+
+	return scores, nil
+}
+
+// Refresh rebuilds the cached scores of the group for every window.
+func Refresh(app core.App, groupId string, now time.Time) error {
+	group, err := app.FindRecordById(groups.Collection, groupId)
+	if err != nil {
+		return err
+	}
+
+	owner, err := app.FindRecordById("users", group.GetString("owner"))
+	if err != nil {
+		return err
+	}
+	clock := period.UserClock(owner)
+
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		return err
+	}
+
+	return app.RunInTransaction(func(txApp core.App) error {
+		_, err := txApp.DB().Delete(Collection, dbx.HashExp{"group": groupId}).Execute()
+		if err != nil {
+			return err
+		}
+
+		for _, window := range Windows {
+			start, err := WindowStart(clock, window, now)
+			if err != nil {
+				return err
+			}
+
+			scores, err := Compute(txApp, groupId, start)
+			if err != nil {
+				return err
+			}
+
+			for _, score := range scores {
+				record := core.NewRecord(collection)
+				record.Set("group", groupId)
+				record.Set("user", score.User)
+				record.Set("window", window)
+				record.Set("points", score.Points)
+				record.Set("rank", score.Rank)
+				record.Set("window_start", start)
+
+				if err := txApp.Save(record); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// RefreshUsers refreshes the leaderboards of every group the users are in.
+func RefreshUsers(app core.App, userIds []string, now time.Time) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	var rows []struct {
+		Group string `db:"group"`
+	}
+
+	err := app.DB().
+		Select("[[group_members.group]] AS [[group]]").
+		Distinct(true).
+		From(groups.MembersCollection).
+		Where(dbx.In("user", toAny(userIds)...)).
+		All(&rows)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := Refresh(app, row.Group, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toAny(values []string) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+
+	return result
+}
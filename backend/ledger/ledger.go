@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	ReasonAward      = "award"
+	ReasonRedemption = "redemption"
+	ReasonAdjustment = "adjustment"
+	ReasonPenalty    = "penalty"
+	ReasonRefund     = "refund"
+	ReasonChallenge  = "challenge"
+)
+
+// Record applies delta to the user's points and appends the matching
+// transaction. source is the record that caused the change, if any.
+func Record(
+	app core.App,
+	user *core.Record,
+	delta int,
+	reason string,
+	source *core.Record,
+) (transaction *core.Record, err error) {
+	err = app.RunInTransaction(func(txApp core.App) error {
+		collection, err := txApp.FindCollectionByNameOrId("point_transactions")
+		if err != nil {
+			return err
+		}
+
+		balance := user.GetInt("points") + delta
+		user.Set("points", balance)
+		if err := txApp.Save(user); err != nil {
+			return err
+		}
+
+		transaction = core.NewRecord(collection)
+		transaction.Set("user", user.Id)
+		transaction.Set("delta", delta)
+		transaction.Set("balance", balance)
+		transaction.Set("reason", reason)
+		if source != nil {
+			transaction.Set("source_collection", source.Collection().Name)
+			transaction.Set("source_id", source.Id)
+		}
+
+		return txApp.Save(transaction)
+	})
+
+	return
+}
+
+func Sum(app core.App, userId string) (int, error) {
+	var sum struct {
+		Total int `db:"total"`
+	}
+
+	err := app.DB().
+		Select("COALESCE(SUM(delta), 0) AS total").
+		From("point_transactions").
+		Where(dbx.HashExp{"user": userId}).
+		One(&sum)
+
+	return sum.Total, err
+}
+
+// Reconcile resets the user's points to the ledger sum, the ledger being the
+// source of truth. It returns the drift that was found, if any.
+func Reconcile(app core.App, user *core.Record) (drift int, err error) {
+	sum, err := Sum(app, user.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	drift = user.GetInt("points") - sum
+	if drift == 0 {
+		return 0, nil
+	}
+
+	user.Set("points", sum)
+
+	return drift, app.Save(user)
+}
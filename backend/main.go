@@ -9,7 +9,10 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/plugins/migratecmd"
 
+	"github.com/dr4ghs/orgtool/api"
 	"github.com/dr4ghs/orgtool/cron"
+	"github.com/dr4ghs/orgtool/export"
+	"github.com/dr4ghs/orgtool/hooks"
 	_ "github.com/dr4ghs/orgtool/migrations"
 )
 
@@ -22,8 +25,16 @@ func main() {
 		Automigrate: isGoRun,
 	})
 
+	app.RootCmd.AddCommand(export.NewCommand(app))
+
 	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		if err := hooks.Bind(app); err != nil {
+			return err
+		}
+
+		cron.CatchUp(app)
 		cron.InitMigrationsCron(app)
+		api.Register(e.Router)
 
 		return e.Next()
 	})
@@ -1,11 +1,8 @@
 package migrations
 
 import (
-	"fmt"
-
 	"github.com/pocketbase/pocketbase/core"
 	m "github.com/pocketbase/pocketbase/migrations"
-	"github.com/pocketbase/pocketbase/tools/hook"
 	"github.com/pocketbase/pocketbase/tools/types"
 )
 
@@ -75,135 +72,6 @@ func removeActivityAPIRules(app core.App) error {
 	return app.Save(collection)
 }
 
-// Hooks -----------------------------------------------------------------------
-
-func injectActivityUserHookBind(app core.App) {
-	app.OnRecordCreateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "activities-onCreateRequest_injectUser",
-		Func: func(e *core.RecordRequestEvent) error {
-			e.Record.Set("user", e.Auth.Id)
-
-			return e.Next()
-		},
-	})
-}
-
-func injectActivityUserHookUnbind(app core.App) {
-	app.OnRecordCreateRequest("activities").Unbind("activities-onCreateRequest_injectUser")
-}
-
-func createActivityEntryHookBind(app core.App) {
-	app.OnRecordAfterCreateSuccess("activities").Unbind("activities_onCreate")
-	app.OnRecordAfterCreateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
-		Id: "activities-onCreateSuccess_createEntry",
-		Func: func(e *core.RecordEvent) error {
-			typ := e.Record.GetString("type")
-			if typ != "daily" && typ != "weekly" && typ != "monthly" && typ != "yearly" {
-				return fmt.Errorf("Not known activity type '%s'\n", typ)
-			}
-
-			collection, err := e.App.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", typ))
-			if err != nil {
-				return err
-			}
-
-			record := core.NewRecord(collection)
-			record.Set("activity", e.Record.Id)
-			record.Set("progress", 0)
-			record.Set("goal", e.Record.GetInt("goal"))
-			record.Set("closed", false)
-
-			if err := e.App.Save(record); err != nil {
-				return err
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func createActivityEntryHookUnbind(app core.App) {
-	app.OnRecordAfterCreateSuccess("activities").Unbind("activities-onCreateSuccess_createEntry")
-}
-
-func preventActivityOwnerChangeHookBind(app core.App) {
-	app.OnRecordUpdateRequest("activities").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "activities-onUpdateRequest_changeOwner",
-		Func: func(e *core.RecordRequestEvent) error {
-			activity, err := e.App.FindRecordById("activities", e.Record.Id)
-			if err != nil {
-				return err
-			}
-
-			if activity.GetString("user") != e.Record.GetString("user") {
-				return fmt.Errorf("Cannot change activity owner")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func preventActivityOwnerChangeHookUnbind(app core.App) {
-	app.OnRecordUpdateRequest("activities").Unbind("activities-onUpdateRequest_changeOwner")
-}
-
-func changeActivityTypeHookBind(app core.App) {
-	app.OnRecordAfterCreateSuccess("activities").Bind(&hook.Handler[*core.RecordEvent]{
-		Id: "activities-onCreateSuccess_changeActivity",
-		Func: func(e *core.RecordEvent) error {
-			activity, err := e.App.FindRecordById("activities", e.Record.Id)
-			if err != nil {
-				return err
-			}
-
-			oldType := activity.GetString("type")
-			newType := e.Record.GetString("type")
-			if oldType == newType {
-				return e.Next()
-			}
-
-			if oldType != "daily" && oldType != "weekly" && oldType != "monthly" &&
-				oldType != "yearly" {
-				return fmt.Errorf("Unknown activity type of '%s'", oldType)
-			}
-
-			oldRecord, err := e.App.FindFirstRecordByFilter(
-				fmt.Sprintf("%s_entries", oldType),
-				"closed = False",
-			)
-			if err != nil {
-				return err
-			}
-
-			collection, err := e.App.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", newType))
-			if err != nil {
-				return err
-			}
-
-			newRecord := core.NewRecord(collection)
-			newRecord.Set("activity", e.Record.Id)
-			newRecord.Set("progress", oldRecord.GetInt("progress"))
-			newRecord.Set("goal", oldRecord.GetInt("goal"))
-			newRecord.Set("closed", false)
-
-			if err := e.App.Delete(oldRecord); err != nil {
-				return err
-			}
-
-			if err := e.App.Save(newRecord); err != nil {
-				return err
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func changeActivityTypeHookUnbind(app core.App) {
-	app.OnRecordAfterCreateSuccess("activities").Unbind("activities-onCreateSuccess_changeActivity")
-}
-
 // =============================================================================
 // REWARDS
 //
@@ -278,49 +146,6 @@ func removeDailyEntriesAPIRules(app core.App) error {
 	return app.Save(collection)
 }
 
-// Hooks -----------------------------------------------------------------------
-
-func checkClosedDailyEntryOnUpdateHookBind(app core.App) {
-	app.OnRecordUpdateRequest("daily_entries").Unbind("daily_entries_onUpdate")
-	app.OnRecordUpdateRequest("daily_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "daily_entries-onUpdateRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			rec, err := e.App.FindRecordById("daily_entries", e.Record.Id)
-			if err != nil {
-				return err
-			}
-
-			if rec.GetBool("closed") {
-				return fmt.Errorf("Is not possible to reopen a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedDailyEntryOnUpdateHookUnbind(app core.App) {
-	app.OnRecordUpdateRequest("daily_entries").Unbind("daily_entries-onUpdateRequest_closed")
-}
-
-func checkClosedDailyEntryOnDeleteHookBind(app core.App) {
-	app.OnRecordUpdateRequest("daily_entries").Unbind("daily_entries_onDelete")
-	app.OnRecordDeleteRequest("daily_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "daily_entries-onDeleteRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			if e.Record.GetBool("closed") {
-				return fmt.Errorf("Is not possible to delete a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedDailyEntryOnDeleteHookUnbind(app core.App) {
-	app.OnRecordDeleteRequest("daily_entries").Unbind("daily_entries-onDeleteRequest_closed")
-}
-
 // =============================================================================
 // WEEKLY ACTIVITIES
 //
@@ -414,47 +239,6 @@ func removeWeeklyEntriesAPIRules(app core.App) error {
 	return app.Save(collection)
 }
 
-// Hooks -----------------------------------------------------------------------
-
-func checkClosedWeeklyEntryOnUpdateHookBind(app core.App) {
-	app.OnRecordUpdateRequest("weekly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "weekly_entries-onUpdateRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			rec, err := e.App.FindRecordById("weekly_entries", e.Record.Id)
-			if err != nil {
-				return err
-			}
-
-			if rec.GetBool("closed") {
-				return fmt.Errorf("Is not possible to reopen a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedWeeklyEntryOnUpdateHookUnbind(app core.App) {
-	app.OnRecordUpdateRequest("weekly_entries").Unbind("weekly_entries-onUpdateRequest_closed")
-}
-
-func checkClosedWeeklyEntryOnDeleteHookBind(app core.App) {
-	app.OnRecordDeleteRequest("weekly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "weekly_entries-onDeleteRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			if e.Record.GetBool("closed") {
-				return fmt.Errorf("Is not possible to delete a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedWeeklyEntryOnDeleteHookUnbind(app core.App) {
-	app.OnRecordDeleteRequest("weekly_entries").Unbind("weekly_entries-onDeleteRequest_closed")
-}
-
 // =============================================================================
 // MONTHLY ACTIVITIES
 //
@@ -548,47 +332,6 @@ func removeMonthlyEntriesAPIRules(app core.App) error {
 	return app.Save(collection)
 }
 
-// Hooks -----------------------------------------------------------------------
-
-func checkClosedMonthlyEntryOnUpdateHookBind(app core.App) {
-	app.OnRecordUpdateRequest("monthly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "monthly_entries-onUpdateRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			rec, err := e.App.FindRecordById("monthly_entries", e.Record.Id)
-			if err != nil {
-				return err
-			}
-
-			if rec.GetBool("closed") {
-				return fmt.Errorf("Is not possible to reopen a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedMonthlyEntryOnUpdateHookUnbind(app core.App) {
-	app.OnRecordUpdateRequest("monthly_entries").Unbind("monthly_entries-onUpdateRequest_closed")
-}
-
-func checkClosedMonthlyEntryOnDeleteHookBind(app core.App) {
-	app.OnRecordDeleteRequest("monthly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "monthly_entries-onDeleteRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			if e.Record.GetBool("closed") {
-				return fmt.Errorf("Is not possible to delete a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedMonthlyEntryOnDeleteHookUnbind(app core.App) {
-	app.OnRecordDeleteRequest("monthly_entries").Unbind("monthly_entries-onDeleteRequest_closed")
-}
-
 // =============================================================================
 // YEARLY ACTIVITIES
 //
@@ -682,47 +425,6 @@ func removeYearlyEntriesAPIRules(app core.App) error {
 	return app.Save(collection)
 }
 
-// Hooks -----------------------------------------------------------------------
-
-func checkClosedYearlyEntryOnUpdateHookBind(app core.App) {
-	app.OnRecordUpdateRequest("yearly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "yearly_entries-onUpdateRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			rec, err := e.App.FindRecordById("yearly_entries", e.Record.Id)
-			if err != nil {
-				return err
-			}
-
-			if rec.GetBool("closed") {
-				return fmt.Errorf("Is not possible to reopen a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedYearlyEntryOnUpdateHookUnbind(app core.App) {
-	app.OnRecordUpdateRequest("yearly_entries").Unbind("yearly_entries-onUpdateRequest_closed")
-}
-
-func checkClosedYearlyEntryOnDeleteHookBind(app core.App) {
-	app.OnRecordDeleteRequest("yearly_entries").Bind(&hook.Handler[*core.RecordRequestEvent]{
-		Id: "yearly_entries-onDeleteRequest_closed",
-		Func: func(e *core.RecordRequestEvent) error {
-			if e.Record.GetBool("closed") {
-				return fmt.Errorf("Is not possible to delete a closed entry")
-			}
-
-			return e.Next()
-		},
-	})
-}
-
-func checkClosedYearlyEntryOnDeleteHookUnbind(app core.App) {
-	app.OnRecordDeleteRequest("yearly_entries").Unbind("yearly_entries-onDeleteRequest_closed")
-}
-
 // =============================================================================
 // MIGRATIONS
 //
@@ -783,34 +485,6 @@ func init() {
 				}
 			}
 
-			// Hooks
-			{ // Activities
-				injectActivityUserHookBind(app)
-				createActivityEntryHookBind(app)
-				preventActivityOwnerChangeHookBind(app)
-				changeActivityTypeHookBind(app)
-			}
-
-			{ // Daily entries
-				checkClosedDailyEntryOnUpdateHookBind(app)
-				checkClosedDailyEntryOnDeleteHookBind(app)
-			}
-
-			{ // Weekly entries
-				checkClosedWeeklyEntryOnUpdateHookBind(app)
-				checkClosedWeeklyEntryOnDeleteHookBind(app)
-			}
-
-			{ // Monthly entries
-				checkClosedMonthlyEntryOnUpdateHookBind(app)
-				checkClosedMonthlyEntryOnDeleteHookBind(app)
-			}
-
-			{ // Yearly entries
-				checkClosedYearlyEntryOnUpdateHookBind(app)
-				checkClosedYearlyEntryOnDeleteHookBind(app)
-			}
-
 			return nil
 		},
 		func(app core.App) error {
@@ -867,34 +541,6 @@ func init() {
 				}
 			}
 
-			// Hooks
-			{ // Activities
-				injectActivityUserHookUnbind(app)
-				createActivityEntryHookUnbind(app)
-				preventActivityOwnerChangeHookUnbind(app)
-				changeActivityTypeHookUnbind(app)
-			}
-
-			{ // Daily entries
-				checkClosedDailyEntryOnUpdateHookUnbind(app)
-				checkClosedDailyEntryOnDeleteHookUnbind(app)
-			}
-
-			{ // Weekly entries
-				checkClosedWeeklyEntryOnUpdateHookUnbind(app)
-				checkClosedWeeklyEntryOnDeleteHookUnbind(app)
-			}
-
-			{ // Monthly entries
-				checkClosedMonthlyEntryOnUpdateHookUnbind(app)
-				checkClosedMonthlyEntryOnDeleteHookUnbind(app)
-			}
-
-			{ // Yearly entries
-				checkClosedYearlyEntryOnUpdateHookUnbind(app)
-				checkClosedYearlyEntryOnDeleteHookUnbind(app)
-			}
-
 			return nil
 		},
 	)
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// =============================================================================
+// USERS
+//
+
+func addUserClockFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.TextField{
+			Name: "timezone",
+			Max:  64,
+		},
+		&core.TextField{
+			Name:    "day_start",
+			Pattern: `^([01][0-9]|2[0-3]):[0-5][0-9]$`,
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeUserClockFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("timezone")
+	collection.Fields.RemoveByName("day_start")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Users
+				if err := addUserClockFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Users
+				if err := removeUserClockFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
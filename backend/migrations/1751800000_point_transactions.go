@@ -0,0 +1,137 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// POINT TRANSACTIONS
+//
+
+func createPointTransactions(app core.App) error {
+	collection := core.NewBaseCollection("point_transactions")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.NumberField{
+			Name:    "delta",
+			OnlyInt: true,
+		},
+		&core.NumberField{
+			Name:    "balance",
+			OnlyInt: true,
+		},
+		&core.SelectField{
+			Name:      "reason",
+			Required:  true,
+			MaxSelect: 1,
+			Values: []string{
+				"award",
+				"redemption",
+				"adjustment",
+			},
+		},
+		&core.TextField{
+			Name: "source_collection",
+		},
+		&core.TextField{
+			Name: "source_id",
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+	)
+
+	collection.AddIndex("idx_point_transactions_user", false, "user, created", "")
+
+	// Only the server writes to the ledger
+	collection.ListRule = types.Pointer("@request.auth.id = user")
+	collection.ViewRule = types.Pointer("@request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deletePointTransactions(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("point_transactions")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+func addOpeningBalances(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("point_transactions")
+	if err != nil {
+		return err
+	}
+
+	users, err := app.FindAllRecords("users", dbx.NewExp("points != 0"))
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		record := core.NewRecord(collection)
+		record.Set("user", user.Id)
+		record.Set("delta", user.GetInt("points"))
+		record.Set("balance", user.GetInt("points"))
+		record.Set("reason", "adjustment")
+
+		if err := app.Save(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Point transactions
+				if err := createPointTransactions(app); err != nil {
+					return err
+				}
+
+				if err := addOpeningBalances(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Point transactions
+				if err := deletePointTransactions(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,256 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/period"
+)
+
+// Period types as they were when these migrations were written, not taken
+// from the period package so later changes don't alter applied migrations
+var periodTypes = []string{"daily", "weekly", "monthly", "yearly"}
+
+// =============================================================================
+// ENTRIES
+//
+
+func addEntriesPeriodFields(app core.App) error {
+	for _, typ := range periodTypes {
+		collection, err := app.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", typ))
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.Add(
+			&core.DateField{
+				Name: "period_start",
+			},
+			&core.DateField{
+				Name: "period_end",
+			},
+			&core.BoolField{
+				Name: "missed",
+			},
+		)
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func removeEntriesPeriodFields(app core.App) error {
+	for _, typ := range periodTypes {
+		collection, err := app.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", typ))
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("period_start")
+		collection.Fields.RemoveByName("period_end")
+		collection.Fields.RemoveByName("missed")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fillEntriesPeriods(app core.App) error {
+	for _, typ := range periodTypes {
+		entries, err := app.FindAllRecords(fmt.Sprintf("%s_entries", typ))
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			activity, err := app.FindRecordById("activities", entry.GetString("activity"))
+			if err != nil {
+				return err
+			}
+
+			user, err := app.FindRecordById("users", activity.GetString("user"))
+			if err != nil {
+				return err
+			}
+
+			start, end, err := period.UserClock(user).Bounds(typ, entry.GetDateTime("created").Time())
+			if err != nil {
+				return err
+			}
+
+			entry.Set("period_start", start)
+			entry.Set("period_end", end)
+
+			if err := app.Save(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// PERIOD WATERMARKS
+//
+
+func createPeriodWatermarks(app core.App) error {
+	collection := core.NewBaseCollection("period_watermarks")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.SelectField{
+			Name:      "period_type",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    periodTypes,
+		},
+		&core.DateField{
+			Name:     "closed_until",
+			Required: true,
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	collection.AddIndex("idx_period_watermarks_user_type", true, "user, period_type", "")
+
+	collection.ListRule = types.Pointer("@request.auth.id = user")
+	collection.ViewRule = types.Pointer("@request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deletePeriodWatermarks(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("period_watermarks")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+func fillPeriodWatermarks(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("period_watermarks")
+	if err != nil {
+		return err
+	}
+
+	users, err := app.FindAllRecords("users")
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		for _, typ := range periodTypes {
+			// Latest period end among the entries that were already closed
+			var watermark struct {
+				ClosedUntil types.DateTime `db:"closed_until"`
+			}
+
+			err := app.DB().
+				Select("MAX(e.period_end) AS closed_until").
+				From(fmt.Sprintf("%s_entries e", typ)).
+				InnerJoin("activities a", dbx.NewExp("a.id = e.activity")).
+				Where(dbx.HashExp{"a.user": user.Id, "e.closed": true}).
+				One(&watermark)
+			if err != nil {
+				return err
+			}
+
+			if watermark.ClosedUntil.IsZero() {
+				continue
+			}
+
+			record := core.NewRecord(collection)
+			record.Set("user", user.Id)
+			record.Set("period_type", typ)
+			record.Set("closed_until", watermark.ClosedUntil)
+
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Entries
+				if err := addEntriesPeriodFields(app); err != nil {
+					return err
+				}
+
+				if err := fillEntriesPeriods(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Period watermarks
+				if err := createPeriodWatermarks(app); err != nil {
+					return err
+				}
+
+				if err := fillPeriodWatermarks(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Period watermarks
+				if err := deletePeriodWatermarks(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Entries
+				if err := removeEntriesPeriodFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
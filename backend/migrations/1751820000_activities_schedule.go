@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// =============================================================================
+// ACTIVITIES
+//
+
+func addActivityScheduleField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	// RRULE-like recurrence, empty means every period
+	collection.Fields.Add(&core.TextField{
+		Name: "schedule",
+		Max:  255,
+	})
+
+	return app.Save(collection)
+}
+
+func removeActivityScheduleField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("schedule")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := addActivityScheduleField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := removeActivityScheduleField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
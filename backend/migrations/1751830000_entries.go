@@ -0,0 +1,300 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const entryColumns = "id, activity, progress, goal, closed, missed, period_start, period_end, created, updated"
+
+// Rule matching the owner of the open entries
+const entriesRule = "@request.auth.id = '' || (@request.auth.id = activity.user && closed = false)"
+
+// =============================================================================
+// ENTRIES
+//
+
+func createEntries(app core.App) error {
+	collection := core.NewBaseCollection("entries")
+
+	// Fields
+	activities, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "activity",
+			Required:      true,
+			CollectionId:  activities.Id,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CascadeDelete: true,
+		},
+		&core.SelectField{
+			Name:      "period_type",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    periodTypes,
+		},
+		&core.DateField{
+			Name: "period_start",
+		},
+		&core.DateField{
+			Name: "period_end",
+		},
+		&core.NumberField{
+			Name:    "progress",
+			OnlyInt: true,
+		},
+		&core.NumberField{
+			Name:     "goal",
+			Required: true,
+			OnlyInt:  true,
+		},
+		&core.BoolField{
+			Name: "closed",
+		},
+		&core.BoolField{
+			Name: "missed",
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	collection.AddIndex("idx_entries_activity", false, "activity, closed", "")
+
+	return app.Save(collection)
+}
+
+func deleteEntries(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+func addEntriesAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	collection.ListRule = types.Pointer(entriesRule)
+	collection.ViewRule = types.Pointer(entriesRule)
+	collection.CreateRule = types.Pointer("@request.auth.id = ''")
+	collection.UpdateRule = types.Pointer(entriesRule)
+	collection.DeleteRule = types.Pointer("@request.auth.id = ''")
+
+	return app.Save(collection)
+}
+
+func moveTypedEntries(app core.App) error {
+	for _, typ := range periodTypes {
+		_, err := app.DB().NewQuery(fmt.Sprintf(
+			"INSERT INTO entries (%[1]s, period_type) SELECT %[1]s, {:type} FROM %[2]s_entries",
+			entryColumns,
+			typ,
+		)).Bind(dbx.Params{"type": typ}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = app.DB().Update(
+			"point_transactions",
+			dbx.Params{"source_collection": "entries"},
+			dbx.HashExp{"source_collection": fmt.Sprintf("%s_entries", typ)},
+		).Execute()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func restoreTypedEntries(app core.App) error {
+	for _, typ := range periodTypes {
+		_, err := app.DB().NewQuery(fmt.Sprintf(
+			"INSERT INTO %[2]s_entries (%[1]s) SELECT %[1]s FROM entries WHERE period_type = {:type}",
+			entryColumns,
+			typ,
+		)).Bind(dbx.Params{"type": typ}).Execute()
+		if err != nil {
+			return err
+		}
+
+		_, err = app.DB().NewQuery(
+			"UPDATE point_transactions SET source_collection = {:collection} " +
+				"WHERE source_collection = 'entries' " +
+				"AND source_id IN (SELECT id FROM entries WHERE period_type = {:type})",
+		).Bind(dbx.Params{
+			"collection": fmt.Sprintf("%s_entries", typ),
+			"type":       typ,
+		}).Execute()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// TYPED ENTRIES
+//
+
+func deleteTypedEntries(app core.App) error {
+	for _, typ := range periodTypes {
+		collection, err := app.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", typ))
+		if err != nil {
+			return err
+		}
+
+		if err := app.Delete(collection); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createTypedEntries(app core.App) error {
+	if err := createDailyEntries(app); err != nil {
+		return err
+	}
+
+	if err := addDailyEntriesAPIRules(app); err != nil {
+		return err
+	}
+
+	if err := createWeeklyEntries(app); err != nil {
+		return err
+	}
+
+	if err := createMonthlyEntries(app); err != nil {
+		return err
+	}
+
+	if err := createYearlyEntries(app); err != nil {
+		return err
+	}
+
+	return addEntriesPeriodFields(app)
+}
+
+// Read-only views keeping the old collection names around for clients that
+// were not ported to entries yet
+func createTypedEntriesViews(app core.App) error {
+	for _, typ := range periodTypes {
+		collection := core.NewViewCollection(fmt.Sprintf("%s_entries", typ))
+		collection.ViewQuery = fmt.Sprintf(
+			"SELECT %s FROM entries WHERE period_type = '%s'",
+			entryColumns,
+			typ,
+		)
+
+		collection.ListRule = types.Pointer(entriesRule)
+		collection.ViewRule = types.Pointer(entriesRule)
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The views are read like the entries they select
+func addTypedEntriesViewsAPIRules(app core.App, rule string) error {
+	for _, typ := range periodTypes {
+		collection, err := app.FindCollectionByNameOrId(fmt.Sprintf("%s_entries", typ))
+		if err != nil {
+			return err
+		}
+
+		collection.ListRule = types.Pointer(rule)
+		collection.ViewRule = types.Pointer(rule)
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Entries
+				if err := createEntries(app); err != nil {
+					return err
+				}
+
+				if err := addEntriesAPIRules(app); err != nil {
+					return err
+				}
+
+				if err := moveTypedEntries(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Typed entries
+				if err := deleteTypedEntries(app); err != nil {
+					return err
+				}
+
+				if err := createTypedEntriesViews(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Typed entries
+				if err := deleteTypedEntries(app); err != nil {
+					return err
+				}
+
+				if err := createTypedEntries(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Entries
+				if err := restoreTypedEntries(app); err != nil {
+					return err
+				}
+
+				if err := deleteEntries(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// PROGRESS EVENTS
+//
+
+func createProgressEvents(app core.App) error {
+	collection := core.NewBaseCollection("progress_events")
+
+	// Fields
+	entries, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "entry",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  entries.Id,
+		},
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.NumberField{
+			Name:    "delta",
+			OnlyInt: true,
+		},
+		&core.NumberField{
+			Name:    "progress",
+			OnlyInt: true,
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+	)
+
+	collection.AddIndex("idx_progress_events_entry", false, "entry, created", "")
+
+	collection.ListRule = types.Pointer("@request.auth.id = user")
+	collection.ViewRule = types.Pointer("@request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deleteProgressEvents(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("progress_events")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Progress events
+				if err := createProgressEvents(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Progress events
+				if err := deleteProgressEvents(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// ACTIVITIES
+//
+
+func addActivityStreakFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.NumberField{
+			Name:    "streak",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		&core.NumberField{
+			Name:    "best_streak",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		// Multiplier schedule overrides, zero keeps the period type default
+		&core.NumberField{
+			Name:    "streak_step",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		&core.NumberField{
+			Name:    "streak_bonus",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		&core.NumberField{
+			Name:    "streak_cap",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeActivityStreakFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("streak")
+	collection.Fields.RemoveByName("best_streak")
+	collection.Fields.RemoveByName("streak_step")
+	collection.Fields.RemoveByName("streak_bonus")
+	collection.Fields.RemoveByName("streak_cap")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := addActivityStreakFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := removeActivityStreakFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,149 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// ACTIVITIES
+//
+
+func addActivityPenaltyFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.NumberField{
+			Name:    "penalty",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		// Unmet periods per month that go unpunished
+		&core.NumberField{
+			Name:    "penalty_grace",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeActivityPenaltyFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("penalty")
+	collection.Fields.RemoveByName("penalty_grace")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// USERS
+//
+
+func addUserPointsFloorField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(&core.NumberField{
+		Name:    "points_floor",
+		OnlyInt: true,
+	})
+
+	return app.Save(collection)
+}
+
+func removeUserPointsFloorField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("points_floor")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// POINT TRANSACTIONS
+//
+
+func setPointTransactionReasons(app core.App, reasons ...string) error {
+	collection, err := app.FindCollectionByNameOrId("point_transactions")
+	if err != nil {
+		return err
+	}
+
+	field, ok := collection.Fields.GetByName("reason").(*core.SelectField)
+	if !ok {
+		return nil
+	}
+	field.Values = reasons
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := addActivityPenaltyFields(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Users
+				if err := addUserPointsFloorField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Point transactions
+				err := setPointTransactionReasons(app, "award", "redemption", "adjustment", "penalty")
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := removeActivityPenaltyFields(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Users
+				if err := removeUserPointsFloorField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Point transactions
+				err := setPointTransactionReasons(app, "award", "redemption", "adjustment")
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// ACTIVITIES
+//
+
+func addActivityScoringFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.SelectField{
+			Name:      "scoring",
+			MaxSelect: 1,
+			Values:    []string{"all_or_nothing", "proportional", "tiered"},
+		},
+		&core.JSONField{
+			Name:    "tiers",
+			MaxSize: 2048,
+		},
+		// Percent of the points proportional scoring pays at most
+		&core.NumberField{
+			Name:    "scoring_cap",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeActivityScoringFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("scoring")
+	collection.Fields.RemoveByName("tiers")
+	collection.Fields.RemoveByName("scoring_cap")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := addActivityScoringFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := removeActivityScoringFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// REWARDS
+//
+
+func addRewardResetFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.SelectField{
+			Name:      "reset_period",
+			MaxSelect: 1,
+			Values:    []string{"never", "daily", "weekly", "monthly", "custom"},
+		},
+		// RRULE-like recurrence used by the custom reset period
+		&core.TextField{
+			Name: "reset_rule",
+			Max:  255,
+		},
+		&core.DateField{
+			Name: "last_reset",
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeRewardResetFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("reset_period")
+	collection.Fields.RemoveByName("reset_rule")
+	collection.Fields.RemoveByName("last_reset")
+
+	return app.Save(collection)
+}
+
+// Existing rewards keep being reset every day
+func fillRewardResetFields(app core.App) error {
+	records, err := app.FindAllRecords("rewards")
+	if err != nil {
+		return err
+	}
+
+	for _, reward := range records {
+		reward.Set("reset_period", "daily")
+		reward.Set("last_reset", types.NowDateTime())
+
+		if err := app.Save(reward); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := addRewardResetFields(app); err != nil {
+					return err
+				}
+
+				if err := fillRewardResetFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := removeRewardResetFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,111 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// REDEMPTIONS
+//
+
+func createRedemptions(app core.App) error {
+	collection := core.NewBaseCollection("redemptions")
+
+	// Fields
+	rewards, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		// Kept when the reward is deleted, the history outlives it
+		&core.RelationField{
+			Name:         "reward",
+			MaxSelect:    1,
+			CollectionId: rewards.Id,
+		},
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.NumberField{
+			Name:     "quantity",
+			Required: true,
+			OnlyInt:  true,
+			Min:      types.Pointer(1.0),
+		},
+		&core.NumberField{
+			Name:    "used",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		&core.NumberField{
+			Name:    "cost",
+			OnlyInt: true,
+		},
+		&core.DateField{
+			Name:     "redeemed_at",
+			Required: true,
+		},
+		&core.DateField{
+			Name: "used_at",
+		},
+	)
+
+	collection.AddIndex("idx_redemptions_user", false, "user, redeemed_at", "")
+	collection.AddIndex("idx_redemptions_reward", false, "reward, redeemed_at", "")
+
+	collection.ListRule = types.Pointer("@request.auth.id = user")
+	collection.ViewRule = types.Pointer("@request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deleteRedemptions(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("redemptions")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Redemptions
+				if err := createRedemptions(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Redemptions
+				if err := deleteRedemptions(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,150 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Refund window given to the rewards that existed before refunds
+const defaultRefundWindow = 24
+
+// =============================================================================
+// REWARDS
+//
+
+func addRewardRefundWindowField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	// Hours after a redemption during which it can be refunded, 0 disables
+	// refunds
+	collection.Fields.Add(&core.NumberField{
+		Name:    "refund_window",
+		OnlyInt: true,
+		Min:     types.Pointer(0.0),
+	})
+
+	if err := app.Save(collection); err != nil {
+		return err
+	}
+
+	records, err := app.FindAllRecords(collection)
+	if err != nil {
+		return err
+	}
+
+	for _, reward := range records {
+		reward.Set("refund_window", defaultRefundWindow)
+
+		if err := app.Save(reward); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func removeRewardRefundWindowField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("refund_window")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// REDEMPTIONS
+//
+
+func addRedemptionRefundFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("redemptions")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.NumberField{
+			Name:    "refunded",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		&core.DateField{
+			Name: "refunded_at",
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeRedemptionRefundFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("redemptions")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("refunded")
+	collection.Fields.RemoveByName("refunded_at")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := addRewardRefundWindowField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Redemptions
+				if err := addRedemptionRefundFields(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Point transactions
+				err := setPointTransactionReasons(app, "award", "redemption", "adjustment", "penalty", "refund")
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := removeRewardRefundWindowField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Redemptions
+				if err := removeRedemptionRefundFields(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Point transactions
+				err := setPointTransactionReasons(app, "award", "redemption", "adjustment", "penalty")
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
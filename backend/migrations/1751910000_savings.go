@@ -0,0 +1,110 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// REWARDS
+//
+
+func addRewardSavedField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	// Points put aside for the reward, spent first when it is redeemed
+	collection.Fields.Add(&core.NumberField{
+		Name:    "saved",
+		OnlyInt: true,
+		Min:     types.Pointer(0.0),
+	})
+
+	return app.Save(collection)
+}
+
+func removeRewardSavedField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("saved")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// USERS
+//
+
+func addUserLockedPointsField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	// Sum of the points saved in the user's rewards
+	collection.Fields.Add(&core.NumberField{
+		Name:    "locked_points",
+		OnlyInt: true,
+		Min:     types.Pointer(0.0),
+	})
+
+	return app.Save(collection)
+}
+
+func removeUserLockedPointsField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("locked_points")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := addRewardSavedField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Users
+				if err := addUserLockedPointsField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := removeRewardSavedField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Users
+				if err := removeUserLockedPointsField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
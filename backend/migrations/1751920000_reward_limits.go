@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// REWARDS
+//
+
+func addRewardLimitFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	// A zero value disables the limit
+	collection.Fields.Add(
+		// Minutes between two redemptions
+		&core.NumberField{
+			Name:    "cooldown",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		// Units that can be redeemed over the reward lifetime
+		&core.NumberField{
+			Name:    "stock",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		// Units that can be redeemed in a week
+		&core.NumberField{
+			Name:    "weekly_cap",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeRewardLimitFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("cooldown")
+	collection.Fields.RemoveByName("stock")
+	collection.Fields.RemoveByName("weekly_cap")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := addRewardLimitFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Rewards
+				if err := removeRewardLimitFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,383 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Rule matching the members of the group of the record
+const groupMemberRule = "(group != '' && group.group_members_via_group.user ?= @request.auth.id)"
+
+// Rule matching the owner and the group members of the open entries
+const groupEntriesRule = "@request.auth.id != '' && " +
+	"(@request.auth.id = activity.user || activity.group.group_members_via_group.user ?= @request.auth.id) && " +
+	"closed = false"
+
+// =============================================================================
+// GROUPS
+//
+
+func createGroups(app core.App) error {
+	collection := core.NewBaseCollection("groups")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.TextField{
+			Name:     "name",
+			Required: true,
+			Max:      255,
+		},
+		&core.RelationField{
+			Name:          "owner",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func addGroupsAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		return err
+	}
+
+	collection.ListRule = types.Pointer(
+		"@request.auth.id = owner || group_members_via_group.user ?= @request.auth.id",
+	)
+	collection.ViewRule = types.Pointer(
+		"@request.auth.id = owner || group_members_via_group.user ?= @request.auth.id",
+	)
+	collection.CreateRule = types.Pointer("@request.auth.id != ''")
+	collection.UpdateRule = types.Pointer("@request.auth.id = owner")
+	collection.DeleteRule = types.Pointer("@request.auth.id = owner")
+
+	return app.Save(collection)
+}
+
+func deleteGroups(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// GROUP MEMBERS
+//
+
+func createGroupMembers(app core.App) error {
+	collection := core.NewBaseCollection("group_members")
+
+	// Fields
+	groupCollection, err := app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		return err
+	}
+
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "group",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  groupCollection.Id,
+		},
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.SelectField{
+			Name:      "role",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"owner", "admin", "member"},
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+	)
+
+	collection.AddIndex("idx_group_members_user", true, "`group`, user", "")
+
+	collection.ListRule = types.Pointer(
+		"@request.auth.id = user || group.group_members_via_group.user ?= @request.auth.id",
+	)
+	collection.ViewRule = types.Pointer(
+		"@request.auth.id = user || group.group_members_via_group.user ?= @request.auth.id",
+	)
+	collection.CreateRule = types.Pointer("@request.auth.id = group.owner")
+	collection.UpdateRule = types.Pointer("@request.auth.id = group.owner")
+	// Members can leave the group
+	collection.DeleteRule = types.Pointer("@request.auth.id = group.owner || @request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deleteGroupMembers(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("group_members")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// ACTIVITIES AND REWARDS
+//
+
+func addGroupField(app core.App, name string) error {
+	collection, err := app.FindCollectionByNameOrId(name)
+	if err != nil {
+		return err
+	}
+
+	groupCollection, err := app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		return err
+	}
+
+	// Items go back to their owner when the group is deleted
+	collection.Fields.Add(&core.RelationField{
+		Name:         "group",
+		MaxSelect:    1,
+		CollectionId: groupCollection.Id,
+	})
+
+	return app.Save(collection)
+}
+
+func removeGroupField(app core.App, name string) error {
+	collection, err := app.FindCollectionByNameOrId(name)
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("group")
+
+	return app.Save(collection)
+}
+
+// Managers other than the owner are checked by the hooks
+func addGroupActivityAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.ListRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+	collection.ViewRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+	collection.UpdateRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+	collection.DeleteRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+
+	return app.Save(collection)
+}
+
+func addGroupRewardsAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	collection.ListRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+	collection.ViewRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+	collection.UpdateRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+	collection.DeleteRule = types.Pointer("@request.auth.id = user || " + groupMemberRule)
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// ENTRIES
+//
+
+func addEntryCompletedByField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	// Member that reached the goal, credited with the points
+	collection.Fields.Add(&core.RelationField{
+		Name:         "completed_by",
+		MaxSelect:    1,
+		CollectionId: userCollection.Id,
+	})
+
+	return app.Save(collection)
+}
+
+func removeEntryCompletedByField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("completed_by")
+
+	return app.Save(collection)
+}
+
+func addGroupEntriesAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	collection.ListRule = types.Pointer(groupEntriesRule)
+	collection.ViewRule = types.Pointer(groupEntriesRule)
+	collection.UpdateRule = types.Pointer(groupEntriesRule)
+
+	if err := app.Save(collection); err != nil {
+		return err
+	}
+
+	return addTypedEntriesViewsAPIRules(app, groupEntriesRule)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Groups
+				if err := createGroups(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Group members
+				if err := createGroupMembers(app); err != nil {
+					return err
+				}
+
+				if err := addGroupsAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Activities
+				if err := addGroupField(app, "activities"); err != nil {
+					return err
+				}
+
+				if err := addGroupActivityAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Rewards
+				if err := addGroupField(app, "rewards"); err != nil {
+					return err
+				}
+
+				if err := addGroupRewardsAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Entries
+				if err := addEntryCompletedByField(app); err != nil {
+					return err
+				}
+
+				if err := addGroupEntriesAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Entries
+				if err := addEntriesAPIRules(app); err != nil {
+					return err
+				}
+
+				if err := addTypedEntriesViewsAPIRules(app, entriesRule); err != nil {
+					return err
+				}
+
+				if err := removeEntryCompletedByField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Rewards
+				if err := addRewardsAPIRules(app); err != nil {
+					return err
+				}
+
+				if err := removeGroupField(app, "rewards"); err != nil {
+					return err
+				}
+			}
+
+			{ // Activities
+				if err := addActivityAPIRules(app); err != nil {
+					return err
+				}
+
+				if err := removeGroupField(app, "activities"); err != nil {
+					return err
+				}
+			}
+
+			{ // Group members
+				if err := deleteGroupMembers(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Groups
+				if err := deleteGroups(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,229 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// USERS
+//
+
+func addUserSupervisionFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:         "supervisors",
+			MaxSelect:    10,
+			CollectionId: collection.Id,
+		},
+		// Awards and redemptions wait for a supervisor first
+		&core.BoolField{
+			Name: "requires_approval",
+		},
+	)
+
+	return app.Save(collection)
+}
+
+func removeUserSupervisionFields(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("supervisors")
+	collection.Fields.RemoveByName("requires_approval")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// ENTRIES
+//
+
+func addEntryPendingApprovalField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(&core.BoolField{
+		Name: "pending_approval",
+	})
+
+	return app.Save(collection)
+}
+
+func removeEntryPendingApprovalField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("pending_approval")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// APPROVALS
+//
+
+func createApprovals(app core.App) error {
+	collection := core.NewBaseCollection("approvals")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	entries, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	rewards, err := app.FindCollectionByNameOrId("rewards")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.SelectField{
+			Name:      "kind",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"entry", "redemption"},
+		},
+		&core.RelationField{
+			Name:          "entry",
+			CascadeDelete: true,
+			MaxSelect:     1,
+			CollectionId:  entries.Id,
+		},
+		&core.RelationField{
+			Name:          "reward",
+			CascadeDelete: true,
+			MaxSelect:     1,
+			CollectionId:  rewards.Id,
+		},
+		&core.NumberField{
+			Name:    "quantity",
+			OnlyInt: true,
+		},
+		// Points awarded or paid once approved
+		&core.NumberField{
+			Name:    "points",
+			OnlyInt: true,
+		},
+		&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"pending", "approved", "rejected", "expired"},
+		},
+		&core.RelationField{
+			Name:         "decided_by",
+			MaxSelect:    1,
+			CollectionId: userCollection.Id,
+		},
+		&core.DateField{
+			Name: "decided_at",
+		},
+		&core.DateField{
+			Name:     "expires_at",
+			Required: true,
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+	)
+
+	collection.AddIndex("idx_approvals_status", false, "status, expires_at", "")
+
+	collection.ListRule = types.Pointer(
+		"@request.auth.id = user || user.supervisors ?= @request.auth.id",
+	)
+	collection.ViewRule = types.Pointer(
+		"@request.auth.id = user || user.supervisors ?= @request.auth.id",
+	)
+
+	return app.Save(collection)
+}
+
+func deleteApprovals(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("approvals")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Users
+				if err := addUserSupervisionFields(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Entries
+				if err := addEntryPendingApprovalField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Approvals
+				if err := createApprovals(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Approvals
+				if err := deleteApprovals(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Entries
+				if err := removeEntryPendingApprovalField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Users
+				if err := removeUserSupervisionFields(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
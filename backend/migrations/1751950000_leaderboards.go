@@ -0,0 +1,152 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// USERS
+//
+
+func addUserLeaderboardOptOutField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(&core.BoolField{
+		Name: "leaderboard_opt_out",
+	})
+
+	return app.Save(collection)
+}
+
+func removeUserLeaderboardOptOutField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("leaderboard_opt_out")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// LEADERBOARD SCORES
+//
+
+func createLeaderboardScores(app core.App) error {
+	collection := core.NewBaseCollection("leaderboard_scores")
+
+	// Fields
+	groupCollection, err := app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		return err
+	}
+
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "group",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  groupCollection.Id,
+		},
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.SelectField{
+			Name:      "window",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"week", "month", "all"},
+		},
+		&core.NumberField{
+			Name:    "points",
+			OnlyInt: true,
+		},
+		&core.NumberField{
+			Name:    "rank",
+			OnlyInt: true,
+		},
+		&core.DateField{
+			Name: "window_start",
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	collection.AddIndex("idx_leaderboard_scores_user", true, "`group`, user, window", "")
+
+	collection.ListRule = types.Pointer("group.group_members_via_group.user ?= @request.auth.id")
+	collection.ViewRule = types.Pointer("group.group_members_via_group.user ?= @request.auth.id")
+
+	return app.Save(collection)
+}
+
+func deleteLeaderboardScores(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("leaderboard_scores")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Users
+				if err := addUserLeaderboardOptOutField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Leaderboard scores
+				if err := createLeaderboardScores(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Leaderboard scores
+				if err := deleteLeaderboardScores(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Users
+				if err := removeUserLeaderboardOptOutField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,286 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// CHALLENGES
+//
+
+func createChallenges(app core.App) error {
+	collection := core.NewBaseCollection("challenges")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	groupCollection, err := app.FindCollectionByNameOrId("groups")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.TextField{
+			Name:     "name",
+			Required: true,
+			Max:      255,
+		},
+		&core.RelationField{
+			Name:          "owner",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		// Only its members can join when set
+		&core.RelationField{
+			Name:         "group",
+			MaxSelect:    1,
+			CollectionId: groupCollection.Id,
+		},
+		// Period type of the activities taking part
+		&core.SelectField{
+			Name:      "type",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    periodTypes,
+		},
+		&core.DateField{
+			Name:     "start",
+			Required: true,
+		},
+		&core.DateField{
+			Name:     "end",
+			Required: true,
+		},
+		&core.SelectField{
+			Name:      "win_rule",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"most_completed", "none_missed"},
+		},
+		// Points each participant puts in the pot when joining
+		&core.NumberField{
+			Name:    "stake",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		&core.NumberField{
+			Name:    "pot",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		},
+		&core.SelectField{
+			Name:      "status",
+			MaxSelect: 1,
+			Values:    []string{"open", "finished"},
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	collection.AddIndex("idx_challenges_status", false, "status, end", "")
+
+	return app.Save(collection)
+}
+
+func addChallengesAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("challenges")
+	if err != nil {
+		return err
+	}
+
+	rule := "@request.auth.id = owner || " +
+		"challenge_participants_via_challenge.user ?= @request.auth.id || " +
+		"(group != '' && group.group_members_via_group.user ?= @request.auth.id)"
+
+	collection.ListRule = types.Pointer(rule)
+	collection.ViewRule = types.Pointer(rule)
+	collection.CreateRule = types.Pointer("@request.auth.id != ''")
+	collection.UpdateRule = types.Pointer("@request.auth.id = owner")
+	collection.DeleteRule = types.Pointer("@request.auth.id = owner")
+
+	return app.Save(collection)
+}
+
+func deleteChallenges(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("challenges")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// CHALLENGE PARTICIPANTS
+//
+
+func createChallengeParticipants(app core.App) error {
+	collection := core.NewBaseCollection("challenge_participants")
+
+	// Fields
+	challengeCollection, err := app.FindCollectionByNameOrId("challenges")
+	if err != nil {
+		return err
+	}
+
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	activities, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "challenge",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  challengeCollection.Id,
+		},
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		// Activity of the user the challenge follows
+		&core.RelationField{
+			Name:          "activity",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  activities.Id,
+		},
+		&core.NumberField{
+			Name:    "completed",
+			OnlyInt: true,
+		},
+		&core.NumberField{
+			Name:    "missed",
+			OnlyInt: true,
+		},
+		&core.BoolField{
+			Name: "winner",
+		},
+		&core.NumberField{
+			Name:    "payout",
+			OnlyInt: true,
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+	)
+
+	collection.AddIndex("idx_challenge_participants_user", true, "challenge, user", "")
+	collection.AddIndex("idx_challenge_participants_activity", false, "activity", "")
+
+	rule := "@request.auth.id = user || @request.auth.id = challenge.owner || " +
+		"challenge.challenge_participants_via_challenge.user ?= @request.auth.id"
+
+	collection.ListRule = types.Pointer(rule)
+	collection.ViewRule = types.Pointer(rule)
+	// Joining, the stake is taken by the hooks
+	collection.CreateRule = types.Pointer("@request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deleteChallengeParticipants(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("challenge_participants")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Challenges
+				if err := createChallenges(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Challenge participants
+				if err := createChallengeParticipants(app); err != nil {
+					return err
+				}
+
+				if err := addChallengesAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Point transactions
+				err := setPointTransactionReasons(
+					app,
+					"award",
+					"redemption",
+					"adjustment",
+					"penalty",
+					"refund",
+					"challenge",
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Point transactions
+				err := setPointTransactionReasons(app, "award", "redemption", "adjustment", "penalty", "refund")
+				if err != nil {
+					return err
+				}
+			}
+
+			{ // Challenge participants
+				if err := deleteChallengeParticipants(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Challenges
+				if err := deleteChallenges(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
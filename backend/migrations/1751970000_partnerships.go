@@ -0,0 +1,178 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// PARTNERSHIPS
+//
+
+func createPartnerships(app core.App) error {
+	collection := core.NewBaseCollection("partnerships")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	activities, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		// User sharing the activities
+		&core.RelationField{
+			Name:          "owner",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		// User allowed to look at them
+		&core.RelationField{
+			Name:          "partner",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.RelationField{
+			Name:         "activities",
+			MaxSelect:    100,
+			CollectionId: activities.Id,
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	collection.AddIndex("idx_partnerships_owner", true, "owner, partner", "")
+
+	collection.ListRule = types.Pointer("@request.auth.id = owner || @request.auth.id = partner")
+	collection.ViewRule = types.Pointer("@request.auth.id = owner || @request.auth.id = partner")
+	collection.CreateRule = types.Pointer("@request.auth.id = owner")
+	collection.UpdateRule = types.Pointer("@request.auth.id = owner")
+	// Either side can end the partnership
+	collection.DeleteRule = types.Pointer("@request.auth.id = owner || @request.auth.id = partner")
+
+	return app.Save(collection)
+}
+
+func deletePartnerships(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("partnerships")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// ACTIVITIES
+//
+
+// Partners can only read, the update and delete rules stay the same
+func addPartnerActivityAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	rule := "@request.auth.id = user || " + groupMemberRule + " || " +
+		"partnerships_via_activities.partner ?= @request.auth.id"
+
+	collection.ListRule = types.Pointer(rule)
+	collection.ViewRule = types.Pointer(rule)
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// ENTRIES
+//
+
+// Partners see the closed entries too, being the activity history
+func addPartnerEntriesAPIRules(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("entries")
+	if err != nil {
+		return err
+	}
+
+	rule := "(" + groupEntriesRule + ") || " +
+		"(@request.auth.id != '' && activity.partnerships_via_activities.partner ?= @request.auth.id)"
+
+	collection.ListRule = types.Pointer(rule)
+	collection.ViewRule = types.Pointer(rule)
+
+	if err := app.Save(collection); err != nil {
+		return err
+	}
+
+	return addTypedEntriesViewsAPIRules(app, rule)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Partnerships
+				if err := createPartnerships(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Activities
+				if err := addPartnerActivityAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Entries
+				if err := addPartnerEntriesAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Entries
+				if err := addGroupEntriesAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Activities
+				if err := addGroupActivityAPIRules(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Partnerships
+				if err := deletePartnerships(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
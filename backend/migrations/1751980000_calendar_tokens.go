@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// CALENDAR TOKENS
+//
+
+func createCalendarTokens(app core.App) error {
+	collection := core.NewBaseCollection("calendar_tokens")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		// Secret part of the feed url, always generated
+		&core.TextField{
+			Name:                "token",
+			Required:            true,
+			Min:                 40,
+			Max:                 40,
+			Pattern:             "^[a-zA-Z0-9]+$",
+			AutogeneratePattern: "[a-zA-Z0-9]{40}",
+		},
+		&core.TextField{
+			Name: "name",
+			Max:  255,
+		},
+		&core.SelectField{
+			Name:      "kind",
+			MaxSelect: 1,
+			Values:    []string{"todo", "event"},
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+	)
+
+	collection.AddIndex("idx_calendar_tokens_token", true, "token", "")
+
+	// Tokens are revoked by deleting them
+	collection.ListRule = types.Pointer("@request.auth.id = user")
+	collection.ViewRule = types.Pointer("@request.auth.id = user")
+	collection.CreateRule = types.Pointer("@request.auth.id = user")
+	collection.UpdateRule = types.Pointer("@request.auth.id = user")
+	collection.DeleteRule = types.Pointer("@request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deleteCalendarTokens(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("calendar_tokens")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Calendar tokens
+				if err := createCalendarTokens(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Calendar tokens
+				if err := deleteCalendarTokens(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,207 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// WEBHOOKS
+//
+
+func createWebhooks(app core.App) error {
+	collection := core.NewBaseCollection("webhooks")
+
+	// Fields
+	userCollection, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  userCollection.Id,
+		},
+		&core.URLField{
+			Name:     "url",
+			Required: true,
+		},
+		// Key of the payload signatures, generated when left empty
+		&core.TextField{
+			Name:                "secret",
+			Required:            true,
+			Min:                 16,
+			Max:                 255,
+			AutogeneratePattern: "[a-zA-Z0-9]{40}",
+		},
+		// Events sent to the webhook, all of them when empty
+		&core.SelectField{
+			Name:      "events",
+			MaxSelect: 4,
+			Values:    []string{"entry.completed", "period.closed", "points.awarded", "reward.redeemed"},
+		},
+		&core.BoolField{
+			Name: "active",
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	collection.AddIndex("idx_webhooks_user", false, "user, active", "")
+
+	collection.ListRule = types.Pointer("@request.auth.id = user")
+	collection.ViewRule = types.Pointer("@request.auth.id = user")
+	collection.CreateRule = types.Pointer("@request.auth.id = user")
+	collection.UpdateRule = types.Pointer("@request.auth.id = user")
+	collection.DeleteRule = types.Pointer("@request.auth.id = user")
+
+	return app.Save(collection)
+}
+
+func deleteWebhooks(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("webhooks")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// WEBHOOK DELIVERIES
+//
+
+func createWebhookDeliveries(app core.App) error {
+	collection := core.NewBaseCollection("webhook_deliveries")
+
+	// Fields
+	webhookCollection, err := app.FindCollectionByNameOrId("webhooks")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.Add(
+		&core.RelationField{
+			Name:          "webhook",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     1,
+			MaxSelect:     1,
+			CollectionId:  webhookCollection.Id,
+		},
+		&core.SelectField{
+			Name:      "event",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"entry.completed", "period.closed", "points.awarded", "reward.redeemed"},
+		},
+		// Body posted to the webhook, the same on every attempt
+		&core.JSONField{
+			Name: "payload",
+		},
+		&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"pending", "delivered", "failed"},
+		},
+		&core.NumberField{
+			Name:    "attempts",
+			OnlyInt: true,
+		},
+		&core.DateField{
+			Name: "next_attempt_at",
+		},
+		&core.DateField{
+			Name: "last_attempt_at",
+		},
+		&core.NumberField{
+			Name:    "response_status",
+			OnlyInt: true,
+		},
+		&core.TextField{
+			Name: "error",
+		},
+		&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		},
+		&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		},
+	)
+
+	collection.AddIndex("idx_webhook_deliveries_status", false, "status, next_attempt_at", "")
+	collection.AddIndex("idx_webhook_deliveries_webhook", false, "webhook, created", "")
+
+	// The delivery log is written by the server only
+	collection.ListRule = types.Pointer("@request.auth.id = webhook.user")
+	collection.ViewRule = types.Pointer("@request.auth.id = webhook.user")
+
+	return app.Save(collection)
+}
+
+func deleteWebhookDeliveries(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("webhook_deliveries")
+	if err != nil {
+		return err
+	}
+
+	return app.Delete(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Webhooks
+				if err := createWebhooks(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Webhook deliveries
+				if err := createWebhookDeliveries(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Webhook deliveries
+				if err := deleteWebhookDeliveries(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Webhooks
+				if err := deleteWebhooks(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// REDEMPTIONS
+//
+
+func addRedemptionSavedField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("redemptions")
+	if err != nil {
+		return err
+	}
+
+	// Part of the cost paid with the reward savings, back to them on refund
+	collection.Fields.Add(&core.NumberField{
+		Name:    "saved",
+		OnlyInt: true,
+		Min:     types.Pointer(0.0),
+	})
+
+	return app.Save(collection)
+}
+
+func removeRedemptionSavedField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("redemptions")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("saved")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Redemptions
+				if err := addRedemptionSavedField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Redemptions
+				if err := removeRedemptionSavedField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,149 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// =============================================================================
+// ACTIVITIES
+//
+
+func addActivityTemplateField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	// Activity this one was created from, followed by the challenges using it
+	collection.Fields.Add(&core.RelationField{
+		Name:         "template",
+		MaxSelect:    1,
+		CollectionId: collection.Id,
+	})
+
+	return app.Save(collection)
+}
+
+func removeActivityTemplateField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("template")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// CHALLENGES
+//
+
+func addChallengeActivityField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("challenges")
+	if err != nil {
+		return err
+	}
+
+	activities, err := app.FindCollectionByNameOrId("activities")
+	if err != nil {
+		return err
+	}
+
+	// Template of the activities taking part, any of the type when unset
+	collection.Fields.Add(&core.RelationField{
+		Name:         "activity",
+		MaxSelect:    1,
+		CollectionId: activities.Id,
+	})
+
+	return app.Save(collection)
+}
+
+func removeChallengeActivityField(app core.App) error {
+	collection, err := app.FindCollectionByNameOrId("challenges")
+	if err != nil {
+		return err
+	}
+
+	collection.Fields.RemoveByName("activity")
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// CHALLENGE PARTICIPANTS
+//
+
+func addChallengeParticipantsAPIRules(app core.App, deleteRule *string) error {
+	collection, err := app.FindCollectionByNameOrId("challenge_participants")
+	if err != nil {
+		return err
+	}
+
+	// The counters and the payout are kept by the server
+	collection.UpdateRule = nil
+	// Leaving, the stake is given back by the hooks
+	collection.DeleteRule = deleteRule
+
+	return app.Save(collection)
+}
+
+// =============================================================================
+// MIGRATIONS
+//
+
+func init() {
+	m.Register(
+		func(app core.App) error {
+			// Tables
+			{ // Activities
+				if err := addActivityTemplateField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Challenges
+				if err := addChallengeActivityField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Challenge participants
+				err := addChallengeParticipantsAPIRules(
+					app,
+					types.Pointer("@request.auth.id = user || @request.auth.id = challenge.owner"),
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(app core.App) error {
+			// Tables
+			{ // Challenge participants
+				if err := addChallengeParticipantsAPIRules(app, nil); err != nil {
+					return err
+				}
+			}
+
+			{ // Challenges
+				if err := removeChallengeActivityField(app); err != nil {
+					return err
+				}
+			}
+
+			{ // Activities
+				if err := removeActivityTemplateField(app); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
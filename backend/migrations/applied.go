@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func Applied(app core.App) (files []string, err error) {
+	err = app.DB().
+		Select("file").
+		From(core.DefaultMigrationsTable).
+		OrderBy("applied ASC", "file ASC").
+		Column(&files)
+
+	return
+}
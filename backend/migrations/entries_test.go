@@ -0,0 +1,157 @@
+package migrations
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// revertEntries reverts the migrations down to the one of the entries
+// included, bringing back the typed entries collections.
+func revertEntries(t testing.TB, app core.App) {
+	files, err := Applied(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := slices.Index(files, "1751830000_entries.go")
+	if index < 0 {
+		t.Fatalf("The entries migration isn't applied: %v", files)
+	}
+
+	if _, err := core.NewMigrationsRunner(app, core.AppMigrations).Down(len(files) - index); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func saveRecord(t testing.TB, app core.App, collection string, id string, fields map[string]any) {
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := core.NewRecord(c)
+	record.Id = id
+	record.Load(fields)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expectEntry checks the entry kept its values in the collection.
+func expectEntry(t testing.TB, app core.App, collection string, id string, progress int, start time.Time) {
+	entry, err := app.FindRecordById(collection, id)
+	if err != nil {
+		t.Fatalf("Entry %s not in %s: %v", id, collection, err)
+	}
+
+	if entry.GetString("activity") != "migrationact001" || entry.GetInt("progress") != progress ||
+		entry.GetInt("goal") != 3 || !entry.GetDateTime("period_start").Time().Equal(start) {
+		t.Errorf("Entry %s of %s changed: %v", id, collection, entry.PublicExport())
+	}
+}
+
+func expectSource(t testing.TB, app core.App, id string, collection string) {
+	transaction, err := app.FindRecordById("point_transactions", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if source := transaction.GetString("source_collection"); source != collection {
+		t.Errorf("Transaction %s points to %s, expected %s", id, source, collection)
+	}
+}
+
+func TestEntriesRoundTrip(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+
+	revertEntries(t, app)
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := core.NewRecord(users)
+	user.Id = "migrationuser01"
+	user.SetEmail("user@example.com")
+	user.SetPassword("1234567890")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	saveRecord(t, app, "activities", "migrationact001", map[string]any{
+		"name":   "Run",
+		"user":   user.Id,
+		"type":   "daily",
+		"goal":   3,
+		"points": 5,
+	})
+
+	daily := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	weekly := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	saveRecord(t, app, "daily_entries", "migrationday001", map[string]any{
+		"activity":     "migrationact001",
+		"progress":     2,
+		"goal":         3,
+		"closed":       true,
+		"period_start": daily,
+		"period_end":   daily.Add(24 * time.Hour),
+	})
+	saveRecord(t, app, "weekly_entries", "migrationweek01", map[string]any{
+		"activity":     "migrationact001",
+		"progress":     1,
+		"goal":         3,
+		"period_start": weekly,
+		"period_end":   weekly.Add(7 * 24 * time.Hour),
+	})
+	saveRecord(t, app, "point_transactions", "migrationtrans1", map[string]any{
+		"user":              user.Id,
+		"amount":            5,
+		"balance":           5,
+		"reason":            "award",
+		"source_collection": "daily_entries",
+		"source_id":         "migrationday001",
+	})
+
+	if _, err := core.NewMigrationsRunner(app, core.AppMigrations).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Moved to the entries, the views reading them back
+	for id, typ := range map[string]string{"migrationday001": "daily", "migrationweek01": "weekly"} {
+		entry, err := app.FindRecordById("entries", id)
+		if err != nil {
+			t.Fatalf("Entry %s wasn't moved: %v", id, err)
+		}
+		if entry.GetString("period_type") != typ {
+			t.Errorf("Entry %s moved as %s", id, entry.GetString("period_type"))
+		}
+	}
+	expectEntry(t, app, "daily_entries", "migrationday001", 2, daily)
+	expectEntry(t, app, "weekly_entries", "migrationweek01", 1, weekly)
+	expectSource(t, app, "migrationtrans1", "entries")
+
+	if _, err := app.FindRecordById("weekly_entries", "migrationday001"); err == nil {
+		t.Errorf("The weekly view reads the daily entry")
+	}
+
+	revertEntries(t, app)
+
+	// Back in their typed collections
+	expectEntry(t, app, "daily_entries", "migrationday001", 2, daily)
+	expectEntry(t, app, "weekly_entries", "migrationweek01", 1, weekly)
+	expectSource(t, app, "migrationtrans1", "daily_entries")
+
+	if _, err := app.FindCollectionByNameOrId("entries"); err == nil {
+		t.Errorf("The entries collection is still there")
+	}
+}
@@ -0,0 +1,117 @@
+package period
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+)
+
+var Types = []string{Daily, Weekly, Monthly, Yearly}
+
+// Day rollover used when the user didn't set one, matching the original
+// 06:00 crontabs.
+const DefaultDayStart = 6 * time.Hour
+
+// Clock describes where a user's days begin: the location their local time
+// is expressed in and the offset from local midnight at which a new day
+// starts.
+type Clock struct {
+	Location *time.Location
+	DayStart time.Duration
+}
+
+func UserClock(user *core.Record) Clock {
+	loc, err := LoadLocation(user.GetString("timezone"))
+	if err != nil {
+		loc = time.UTC
+	}
+
+	dayStart, err := ParseDayStart(user.GetString("day_start"))
+	if err != nil {
+		dayStart = DefaultDayStart
+	}
+
+	return Clock{Location: loc, DayStart: dayStart}
+}
+
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	return time.LoadLocation(name)
+}
+
+func ParseDayStart(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultDayStart, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid day start '%s'", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Bounds returns the [start, end) interval of the period of the given type
+// that contains t.
+func (c Clock) Bounds(typ string, t time.Time) (start time.Time, end time.Time, err error) {
+	y, m, d := c.logicalDate(t)
+
+	switch typ {
+	case Daily:
+		return c.at(y, m, d), c.at(y, m, d+1), nil
+	case Weekly:
+		// Weeks start on monday
+		offset := (int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Weekday()) + 6) % 7
+		return c.at(y, m, d-offset), c.at(y, m, d-offset+7), nil
+	case Monthly:
+		return c.at(y, m, 1), c.at(y, m+1, 1), nil
+	case Yearly:
+		return c.at(y, time.January, 1), c.at(y+1, time.January, 1), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("Not known period type '%s'", typ)
+}
+
+// Day returns the logical date t belongs to, as midnight UTC.
+func (c Clock) Day(t time.Time) time.Time {
+	y, m, d := c.logicalDate(t)
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// StartOf returns when the logical day of the given calendar date begins.
+func (c Clock) StartOf(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return c.at(y, m, d)
+}
+
+// logicalDate returns the calendar date t belongs to once the day rollover is
+// taken into account, e.g. 03:00 with a 06:00 rollover is still yesterday.
+func (c Clock) logicalDate(t time.Time) (int, time.Month, int) {
+	y, m, d := t.In(c.Location).Date()
+	if t.Before(c.at(y, m, d)) {
+		y, m, d = time.Date(y, m, d-1, 0, 0, 0, 0, time.UTC).Date()
+	}
+
+	return y, m, d
+}
+
+func (c Clock) at(y int, m time.Month, d int) time.Time {
+	// Built from the wall clock rather than midnight plus an offset so the
+	// rollover stays at the same local hour across DST changes.
+	hour := int(c.DayStart / time.Hour)
+	minute := int(c.DayStart % time.Hour / time.Minute)
+
+	return time.Date(y, m, d, hour, minute, 0, 0, c.Location)
+}
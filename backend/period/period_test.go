@@ -0,0 +1,186 @@
+package period
+
+import (
+	"testing"
+	"time"
+)
+
+func location(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loc
+}
+
+func TestBoundsAcrossDST(t *testing.T) {
+	const layout = "2006-01-02 15:04 MST"
+
+	cases := []struct {
+		name     string
+		location string
+		dayStart time.Duration
+		typ      string
+		at       string
+		start    string
+		length   time.Duration
+	}{
+		{
+			name:     "rome spring forward day",
+			location: "Europe/Rome",
+			dayStart: 6 * time.Hour,
+			typ:      Daily,
+			at:       "2025-03-30 05:30 CEST",
+			start:    "2025-03-29 06:00 CET",
+			length:   23 * time.Hour,
+		},
+		{
+			name:     "rome day after spring forward",
+			location: "Europe/Rome",
+			dayStart: 6 * time.Hour,
+			typ:      Daily,
+			at:       "2025-03-30 06:00 CEST",
+			start:    "2025-03-30 06:00 CEST",
+			length:   24 * time.Hour,
+		},
+		{
+			name:     "rome fall back day",
+			location: "Europe/Rome",
+			dayStart: 6 * time.Hour,
+			typ:      Daily,
+			at:       "2025-10-25 23:00 CEST",
+			start:    "2025-10-25 06:00 CEST",
+			length:   25 * time.Hour,
+		},
+		{
+			name:     "rome midnight rollover on spring forward",
+			location: "Europe/Rome",
+			dayStart: 0,
+			typ:      Daily,
+			at:       "2025-03-30 01:30 CET",
+			start:    "2025-03-30 00:00 CET",
+			length:   23 * time.Hour,
+		},
+		{
+			name:     "rome repeated hour before a 03:00 rollover",
+			location: "Europe/Rome",
+			dayStart: 3 * time.Hour,
+			typ:      Daily,
+			at:       "2025-10-26 02:30 CET",
+			start:    "2025-10-25 03:00 CEST",
+			length:   25 * time.Hour,
+		},
+		{
+			name:     "rome month with spring forward",
+			location: "Europe/Rome",
+			dayStart: 6 * time.Hour,
+			typ:      Monthly,
+			at:       "2025-03-15 12:00 CET",
+			start:    "2025-03-01 06:00 CET",
+			length:   31*24*time.Hour - time.Hour,
+		},
+		{
+			name:     "new york spring forward day",
+			location: "America/New_York",
+			dayStart: 6 * time.Hour,
+			typ:      Daily,
+			at:       "2025-03-09 12:00 EDT",
+			start:    "2025-03-09 06:00 EDT",
+			length:   24 * time.Hour,
+		},
+		{
+			name:     "new york day before spring forward",
+			location: "America/New_York",
+			dayStart: 6 * time.Hour,
+			typ:      Daily,
+			at:       "2025-03-09 04:00 EDT",
+			start:    "2025-03-08 06:00 EST",
+			length:   23 * time.Hour,
+		},
+		{
+			name:     "new york week with fall back",
+			location: "America/New_York",
+			dayStart: 0,
+			typ:      Weekly,
+			at:       "2025-11-02 01:30 EST",
+			start:    "2025-10-27 00:00 EDT",
+			length:   7*24*time.Hour + time.Hour,
+		},
+		{
+			name:     "new york year",
+			location: "America/New_York",
+			dayStart: 6 * time.Hour,
+			typ:      Yearly,
+			at:       "2025-07-04 12:00 EDT",
+			start:    "2025-01-01 06:00 EST",
+			length:   365 * 24 * time.Hour,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			loc := location(t, c.location)
+			clock := Clock{Location: loc, DayStart: c.dayStart}
+
+			at, err := time.ParseInLocation(layout, c.at, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start, end, err := clock.Bounds(c.typ, at)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := start.In(loc).Format(layout); got != c.start {
+				t.Errorf("Start is %s, expected %s", got, c.start)
+			}
+
+			if got := end.Sub(start); got != c.length {
+				t.Errorf("Length is %v, expected %v", got, c.length)
+			}
+
+			if at.Before(start) || !at.Before(end) {
+				t.Errorf("%s is outside [%s, %s)", at, start, end)
+			}
+		})
+	}
+}
+
+func TestDayAcrossDST(t *testing.T) {
+	rome := location(t, "Europe/Rome")
+	clock := Clock{Location: rome, DayStart: 3 * time.Hour}
+
+	cases := []struct {
+		at  time.Time
+		day string
+	}{
+		// 02:00 to 03:00 doesn't exist on the spring forward night
+		{time.Date(2025, time.March, 30, 0, 59, 0, 0, time.UTC), "2025-03-29"},
+		{time.Date(2025, time.March, 30, 1, 0, 0, 0, time.UTC), "2025-03-30"},
+		// Both 02:30 of the fall back night belong to the previous day
+		{time.Date(2025, time.October, 26, 0, 30, 0, 0, time.UTC), "2025-10-25"},
+		{time.Date(2025, time.October, 26, 1, 30, 0, 0, time.UTC), "2025-10-25"},
+		{time.Date(2025, time.October, 26, 2, 0, 0, 0, time.UTC), "2025-10-26"},
+	}
+
+	for _, c := range cases {
+		if got := clock.Day(c.at).Format(time.DateOnly); got != c.day {
+			t.Errorf("Day(%s) = %s, expected %s", c.at.In(rome), got, c.day)
+		}
+	}
+}
+
+func TestStartOfKeepsLocalHour(t *testing.T) {
+	ny := location(t, "America/New_York")
+	clock := Clock{Location: ny, DayStart: 6 * time.Hour}
+
+	day := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 366; i++ {
+		start := clock.StartOf(day.AddDate(0, 0, i)).In(ny)
+		if start.Hour() != 6 || start.Minute() != 0 {
+			t.Fatalf("Day %s starts at %s", day.AddDate(0, 0, i).Format(time.DateOnly), start)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported subset of RFC 5545 recurrence rules. Rules are evaluated on
+// calendar dates only: times of day are ignored.
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Longest gap between two occurrences we look through, enough for a yearly
+// rule on February 29th.
+const searchYears = 8
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY value: a weekday optionally prefixed by its ordinal
+// within the month or year, e.g. 1SU or -1FR.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("Invalid rule part '%s'", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+		case "INTERVAL":
+			rule.Interval, err = parseInt(val, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(val, 1, 10000)
+		case "UNTIL":
+			rule.Until, err = parseDate(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val, 1, 12)
+			for _, month := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(month))
+			}
+		case "WKST":
+			var ok bool
+			if rule.WeekStart, ok = weekdays[strings.ToUpper(val)]; !ok {
+				err = fmt.Errorf("Invalid week start '%s'", val)
+			}
+		default:
+			err = fmt.Errorf("Unsupported rule part '%s'", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *Rule) validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return fmt.Errorf("Missing rule frequency")
+	default:
+		return fmt.Errorf("Unsupported rule frequency '%s'", r.Freq)
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("COUNT and UNTIL cannot be used together")
+	}
+
+	for _, day := range r.ByDay {
+		if day.N == 0 {
+			continue
+		}
+
+		switch {
+		case r.Freq == Daily || r.Freq == Weekly:
+			return fmt.Errorf("BYDAY ordinals are only allowed on monthly and yearly rules")
+		case r.monthScoped() && (day.N < -5 || day.N > 5):
+			return fmt.Errorf("BYDAY ordinal %d is out of the month range", day.N)
+		}
+	}
+
+	return nil
+}
+
+// Occurs reports whether day is an occurrence of the rule started at dtstart.
+func (r *Rule) Occurs(dtstart time.Time, day time.Time) bool {
+	start := date(dtstart)
+	d := date(day)
+
+	if d.Before(start) || !r.matches(start, d) {
+		return false
+	}
+
+	if !r.Until.IsZero() && d.After(r.Until) {
+		return false
+	}
+
+	if r.Count > 0 {
+		count := 0
+		for cur := start; !cur.After(d); cur = cur.AddDate(0, 0, 1) {
+			if r.matches(start, cur) {
+				count++
+			}
+		}
+
+		return count <= r.Count
+	}
+
+	return true
+}
+
+// Prev returns the last occurrence on or before from.
+func (r *Rule) Prev(dtstart time.Time, from time.Time) (time.Time, bool) {
+	start := date(dtstart)
+	cur := date(from)
+	if !r.Until.IsZero() && cur.After(r.Until) {
+		cur = r.Until
+	}
+
+	// Occurrences have to be counted from the start
+	if r.Count > 0 {
+		var last time.Time
+		count := 0
+		for c := start; !c.After(cur) && count < r.Count; c = c.AddDate(0, 0, 1) {
+			if r.matches(start, c) {
+				last = c
+				count++
+			}
+		}
+
+		return last, count > 0
+	}
+
+	limit := cur.AddDate(-searchYears*r.Interval, 0, 0)
+	for ; !cur.Before(start) && cur.After(limit); cur = cur.AddDate(0, 0, -1) {
+		if r.matches(start, cur) {
+			return cur, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (r *Rule) matches(start time.Time, d time.Time) bool {
+	if !r.inInterval(start, d) {
+		return false
+	}
+
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, d.Month()) {
+		return false
+	}
+
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(d) {
+		return false
+	}
+
+	if len(r.ByDay) > 0 && !r.matchesByDay(d) {
+		return false
+	}
+
+	// Without BY* parts the rule repeats on the same day as the start
+	switch r.Freq {
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return d.Weekday() == start.Weekday()
+		}
+	case Monthly:
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			return d.Day() == start.Day()
+		}
+	case Yearly:
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			if len(r.ByMonth) == 0 && d.Month() != start.Month() {
+				return false
+			}
+
+			return d.Day() == start.Day()
+		}
+	}
+
+	return true
+}
+
+func (r *Rule) inInterval(start time.Time, d time.Time) bool {
+	var elapsed int
+
+	switch r.Freq {
+	case Daily:
+		elapsed = days(start, d)
+	case Weekly:
+		elapsed = days(r.weekOf(start), r.weekOf(d)) / 7
+	case Monthly:
+		elapsed = (d.Year()-start.Year())*12 + int(d.Month()) - int(start.Month())
+	case Yearly:
+		elapsed = d.Year() - start.Year()
+	}
+
+	return elapsed%r.Interval == 0
+}
+
+func (r *Rule) matchesMonthDay(d time.Time) bool {
+	last := daysIn(d.Year(), d.Month())
+
+	for _, day := range r.ByMonthDay {
+		if day > 0 && d.Day() == day {
+			return true
+		}
+
+		if day < 0 && d.Day() == last+day+1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchesByDay(d time.Time) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday != d.Weekday() {
+			continue
+		}
+
+		if day.N == 0 || r.Freq == Daily || r.Freq == Weekly {
+			return true
+		}
+
+		// Position of d among the same weekdays of its month or year
+		var index, total int
+		if r.monthScoped() {
+			index = d.Day()
+			total = daysIn(d.Year(), d.Month())
+		} else {
+			index = d.YearDay()
+			total = time.Date(d.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+		}
+
+		fromStart := (index-1)/7 + 1
+		fromEnd := -((total-index)/7 + 1)
+		if day.N == fromStart || day.N == fromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+// monthScoped reports whether BYDAY ordinals count within the month
+func (r *Rule) monthScoped() bool {
+	return r.Freq == Monthly || (r.Freq == Yearly && len(r.ByMonth) > 0)
+}
+
+func (r *Rule) weekOf(d time.Time) time.Time {
+	offset := (int(d.Weekday()) - int(r.WeekStart) + 7) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// =============================================================================
+// HELPERS
+//
+
+func date(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func days(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func parseInt(value string, lo int, hi int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("Invalid number '%s'", value)
+	}
+
+	return n, nil
+}
+
+func parseIntList(value string, lo int, hi int) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := parseInt(item, lo, hi)
+		if err != nil {
+			return nil, err
+		}
+
+		if n == 0 {
+			return nil, fmt.Errorf("Invalid number '%s'", item)
+		}
+
+		list = append(list, n)
+	}
+
+	return list, nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var list []WeekdayNum
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("Invalid weekday '%s'", item)
+		}
+
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("Invalid weekday '%s'", item)
+		}
+
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			if n, err = parseInt(strings.TrimPrefix(prefix, "+"), -53, 53); err != nil || n == 0 {
+				return nil, fmt.Errorf("Invalid weekday '%s'", item)
+			}
+		}
+
+		list = append(list, WeekdayNum{N: n, Weekday: weekday})
+	}
+
+	return list, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return date(t), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid date '%s'", value)
+}
@@ -0,0 +1,218 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// occurrences returns every day in [from, to) the rule occurs on.
+func occurrences(rule *Rule, dtstart time.Time, from time.Time, to time.Time) []time.Time {
+	var list []time.Time
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		if rule.Occurs(dtstart, d) {
+			list = append(list, d)
+		}
+	}
+
+	return list
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6SU",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=DAILY;BYSECOND=1",
+		"FREQ=DAILY;WKST=XX",
+	} {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) succeeded, expected an error", value)
+		}
+	}
+}
+
+func TestOccursOverLongRanges(t *testing.T) {
+	start := day(2020, time.January, 1)
+	end := day(2040, time.January, 1)
+
+	cases := []struct {
+		name  string
+		rule  string
+		check func(t *testing.T, days []time.Time)
+	}{
+		{
+			name: "every 2 days",
+			rule: "FREQ=DAILY;INTERVAL=2",
+			check: func(t *testing.T, days []time.Time) {
+				for i := 1; i < len(days); i++ {
+					if gap := days[i].Sub(days[i-1]); gap != 48*time.Hour {
+						t.Fatalf("Gap of %v between %s and %s", gap, days[i-1], days[i])
+					}
+				}
+			},
+		},
+		{
+			name: "monday, wednesday and friday",
+			rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			check: func(t *testing.T, days []time.Time) {
+				for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+					w := d.Weekday()
+					expected := w == time.Monday || w == time.Wednesday || w == time.Friday
+					if expected != contains(days, d) {
+						t.Fatalf("Occurs on %s (%s) is %v", d, w, !expected)
+					}
+				}
+			},
+		},
+		{
+			name: "every 3 weeks",
+			rule: "FREQ=WEEKLY;INTERVAL=3",
+			check: func(t *testing.T, days []time.Time) {
+				for i := 1; i < len(days); i++ {
+					if gap := days[i].Sub(days[i-1]); gap != 21*24*time.Hour {
+						t.Fatalf("Gap of %v between %s and %s", gap, days[i-1], days[i])
+					}
+				}
+			},
+		},
+		{
+			name: "first sunday of the month",
+			rule: "FREQ=MONTHLY;BYDAY=1SU",
+			check: func(t *testing.T, days []time.Time) {
+				if len(days) != 20*12 {
+					t.Fatalf("Expected one occurrence per month, got %d", len(days))
+				}
+
+				for _, d := range days {
+					if d.Weekday() != time.Sunday || d.Day() > 7 {
+						t.Fatalf("%s is not the first sunday of its month", d)
+					}
+				}
+			},
+		},
+		{
+			name: "last friday of the month",
+			rule: "FREQ=MONTHLY;BYDAY=-1FR",
+			check: func(t *testing.T, days []time.Time) {
+				if len(days) != 20*12 {
+					t.Fatalf("Expected one occurrence per month, got %d", len(days))
+				}
+
+				for _, d := range days {
+					if d.Weekday() != time.Friday || d.AddDate(0, 0, 7).Month() == d.Month() {
+						t.Fatalf("%s is not the last friday of its month", d)
+					}
+				}
+			},
+		},
+		{
+			name: "last day of the month",
+			rule: "FREQ=MONTHLY;BYMONTHDAY=-1",
+			check: func(t *testing.T, days []time.Time) {
+				for _, d := range days {
+					if d.AddDate(0, 0, 1).Day() != 1 {
+						t.Fatalf("%s is not the last day of its month", d)
+					}
+				}
+			},
+		},
+		{
+			name: "february 29th",
+			rule: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29",
+			check: func(t *testing.T, days []time.Time) {
+				if len(days) != 5 {
+					t.Fatalf("Expected the 5 leap days, got %v", days)
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := Parse(c.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			days := occurrences(rule, start, start, end)
+			if len(days) == 0 {
+				t.Fatal("No occurrences")
+			}
+
+			c.check(t, days)
+		})
+	}
+}
+
+func TestCountAndUntil(t *testing.T) {
+	start := day(2024, time.March, 1)
+
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=TU,TH;COUNT=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(occurrences(rule, start, start, start.AddDate(5, 0, 0))); n != 10 {
+		t.Errorf("COUNT=10 occurred %d times", n)
+	}
+
+	rule, err = Parse("FREQ=DAILY;UNTIL=20240310")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	days := occurrences(rule, start, start, start.AddDate(5, 0, 0))
+	if len(days) != 10 || !days[len(days)-1].Equal(day(2024, time.March, 10)) {
+		t.Errorf("UNTIL occurrences are %v", days)
+	}
+}
+
+func TestPrevMatchesOccurs(t *testing.T) {
+	start := day(2021, time.June, 15)
+
+	for _, value := range []string{
+		"FREQ=DAILY;INTERVAL=3",
+		"FREQ=WEEKLY;BYDAY=SA,SU",
+		"FREQ=MONTHLY;BYDAY=2WE",
+		"FREQ=MONTHLY;BYMONTHDAY=31",
+		"FREQ=YEARLY",
+		"FREQ=WEEKLY;COUNT=5",
+		"FREQ=DAILY;UNTIL=20230101",
+	} {
+		rule, err := Parse(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var last time.Time
+		for d := start.AddDate(0, 0, -10); d.Before(start.AddDate(4, 0, 0)); d = d.AddDate(0, 0, 1) {
+			if rule.Occurs(start, d) {
+				last = d
+			}
+
+			prev, ok := rule.Prev(start, d)
+			if ok != !last.IsZero() || !prev.Equal(last) {
+				t.Fatalf("%s: Prev(%s) = %s, %v, expected %s", value, d, prev, ok, last)
+			}
+		}
+	}
+}
+
+func contains(days []time.Time, d time.Time) bool {
+	for _, day := range days {
+		if day.Equal(d) {
+			return true
+		}
+	}
+
+	return false
+}
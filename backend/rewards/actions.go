@@ -0,0 +1,163 @@
+package rewards
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/ledger"
+)
+
+var (
+	ErrQuantity        = errors.New("The quantity must be greater than zero")
+	ErrMaxRedeemables  = errors.New("Redeemed rewards exceded the max redeemables limit")
+	ErrNotEnoughPoints = errors.New("Not enough points to redeem reward")
+	ErrAllUsed         = errors.New("Already used all redeemed rewards")
+	ErrNotRefundable   = errors.New("Not enough unused rewards to refund")
+	ErrRefundWindow    = errors.New("The refund window of the redemption is over")
+)
+
+// Redeem charges quantity units of the reward to the user, its owner when
+// empty, and bumps the redeemed counter. Everything is saved in one
+// transaction so a failure never leaves points debited for nothing.
+func Redeem(
+	app core.App,
+	rewardId string,
+	userId string,
+	quantity int,
+) (reward *core.Record, user *core.Record, err error) {
+	if quantity <= 0 {
+		return nil, nil, ErrQuantity
+	}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		reward, err = txApp.FindRecordById(Collection, rewardId)
+		if err != nil {
+			return err
+		}
+
+		redeemed := reward.GetInt("redeemed") + quantity
+		if redeemed > reward.GetInt("max_redeemables") {
+			return ErrMaxRedeemables
+		}
+
+		if userId == "" {
+			userId = reward.GetString("user")
+		}
+
+		user, err = txApp.FindRecordById("users", userId)
+		if err != nil {
+			return err
+		}
+
+		if _, err := Charge(txApp, reward, user, quantity); err != nil {
+			return err
+		}
+
+		reward.Set("redeemed", redeemed)
+
+		return txApp.Save(reward)
+	})
+
+	return
+}
+
+// Use marks quantity redeemed units of the reward as used.
+func Use(app core.App, rewardId string, quantity int) (reward *core.Record, err error) {
+	if quantity <= 0 {
+		return nil, ErrQuantity
+	}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		reward, err = txApp.FindRecordById(Collection, rewardId)
+		if err != nil {
+			return err
+		}
+
+		used := reward.GetInt("used") + quantity
+		if used > reward.GetInt("redeemed") {
+			return ErrAllUsed
+		}
+
+		if err := UseRedemptions(txApp, reward, quantity, time.Now()); err != nil {
+			return err
+		}
+
+		reward.Set("used", used)
+
+		return txApp.Save(reward)
+	})
+
+	return
+}
+
+// Hours the redemptions of a new reward can be refunded, unless it sets its
+// own refund window. A zero window disables refunds.
+const DefaultRefundWindow = 24
+
+// Refund gives back the points paid for quantity unused units of the
+// redemption, as long as the reward refund window is still open.
+func Refund(app core.App, redemptionId string, quantity int) (redemption *core.Record, user *core.Record, err error) {
+	if quantity <= 0 {
+		return nil, nil, ErrQuantity
+	}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		redemption, err = txApp.FindRecordById(RedemptionsCollection, redemptionId)
+		if err != nil {
+			return err
+		}
+
+		if quantity > Unused(redemption) {
+			return ErrNotRefundable
+		}
+
+		reward, err := txApp.FindRecordById(Collection, redemption.GetString("reward"))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		window := time.Duration(reward.GetInt("refund_window")) * time.Hour
+		if now.After(redemption.GetDateTime("redeemed_at").Time().Add(window)) {
+			return ErrRefundWindow
+		}
+
+		user, err = txApp.FindRecordById("users", redemption.GetString("user"))
+		if err != nil {
+			return err
+		}
+
+		// Same unit price the redemption was paid with, the part taken from
+		// the reward savings going back to them
+		amount := redemption.GetInt("cost") * quantity / redemption.GetInt("quantity")
+		saved := redemption.GetInt("saved") * quantity / redemption.GetInt("quantity")
+
+		user.Set("locked_points", user.GetInt("locked_points")+saved)
+		if _, err := ledger.Record(txApp, user, amount, ledger.ReasonRefund, redemption); err != nil {
+			return err
+		}
+
+		redemption.Set("refunded", redemption.GetInt("refunded")+quantity)
+		redemption.Set("refunded_at", now)
+		if err := txApp.Save(redemption); err != nil {
+			return err
+		}
+
+		reward.Set("saved", reward.GetInt("saved")+saved)
+
+		// Redemptions from before the last reset don't count anymore
+		if !redemption.GetDateTime("redeemed_at").Time().Before(reward.GetDateTime("last_reset").Time()) {
+			redeemed := max(reward.GetInt("redeemed")-quantity, 0)
+			if reward.GetInt("used") > redeemed {
+				return ErrAllUsed
+			}
+			reward.Set("redeemed", redeemed)
+		}
+
+		return txApp.Save(reward)
+	})
+
+	return
+}
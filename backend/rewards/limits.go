@@ -0,0 +1,290 @@
+package rewards
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+
+	"github.com/dr4ghs/orgtool/period"
+)
+
+var ErrLimit = errors.New("Reward limit reached")
+
+// CheckLimits fails when quantity more units of the reward cannot be redeemed
+// at now because of its cooldown, stock or weekly cap.
+func CheckLimits(app core.App, clock period.Clock, reward *core.Record, quantity int, now time.Time) error {
+	if cooldown := reward.GetInt("cooldown"); cooldown > 0 {
+		last, err := lastRedeemed(app, reward)
+		if err != nil {
+			return err
+		}
+
+		if until := last.Add(time.Duration(cooldown) * time.Minute); now.Before(until) {
+			return fmt.Errorf(
+				"%w: the reward is on cooldown until %s",
+				ErrLimit,
+				until.In(clock.Location).Format(time.RFC3339),
+			)
+		}
+	}
+
+	if stock := reward.GetInt("stock"); stock > 0 {
+		redeemed, err := countRedeemed(app, reward, time.Time{})
+		if err != nil {
+			return err
+		}
+
+		if left := max(stock-redeemed, 0); quantity > left {
+			return fmt.Errorf("%w: only %d units of the reward are left in stock", ErrLimit, left)
+		}
+	}
+
+	if weeklyCap := reward.GetInt("weekly_cap"); weeklyCap > 0 {
+		week, _, err := clock.Bounds(period.Weekly, now)
+		if err != nil {
+			return err
+		}
+
+		redeemed, err := countRedeemed(app, reward, week)
+		if err != nil {
+			return err
+		}
+
+		if left := max(weeklyCap-redeemed, 0); quantity > left {
+			return fmt.Errorf(
+				"%w: the weekly cap is %d units and only %d are left this week",
+				ErrLimit,
+				weeklyCap,
+				left,
+			)
+		}
+	}
+
+	return nil
+}
+
+// NextAvailable returns when the next unit of the reward can be redeemed, now
+// if it already can. A zero time means the stock ran out.
+func NextAvailable(app core.App, clock period.Clock, reward *core.Record, now time.Time) (time.Time, error) {
+	week, _, err := clock.Bounds(period.Weekly, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	usage, err := loadUsage(app, []*core.Record{reward}, map[string]time.Time{reward.Id: week})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return nextAvailable(clock, reward, usage[reward.Id], now)
+}
+
+// NextAvailableAll is NextAvailable for many rewards at once, each one on the
+// clock of its owner, with the same few queries whatever their number.
+func NextAvailableAll(app core.App, records []*core.Record, now time.Time) (map[string]time.Time, error) {
+	userIds := make([]string, 0, len(records))
+	for _, reward := range records {
+		userIds = append(userIds, reward.GetString("user"))
+	}
+
+	users, err := app.FindRecordsByIds("users", userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	clocks := make(map[string]period.Clock, len(users))
+	for _, user := range users {
+		clocks[user.Id] = period.UserClock(user)
+	}
+
+	weeks := make(map[string]time.Time, len(records))
+	for _, reward := range records {
+		week, _, err := clocks[reward.GetString("user")].Bounds(period.Weekly, now)
+		if err != nil {
+			return nil, err
+		}
+
+		weeks[reward.Id] = week
+	}
+
+	usage, err := loadUsage(app, records, weeks)
+	if err != nil {
+		return nil, err
+	}
+
+	next := make(map[string]time.Time, len(records))
+	for _, reward := range records {
+		next[reward.Id], err = nextAvailable(clocks[reward.GetString("user")], reward, usage[reward.Id], now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return next, nil
+}
+
+// limitUsage is what the limits of a reward are checked against, counting the
+// units that were not refunded.
+type limitUsage struct {
+	// Units redeemed over the reward lifetime
+	total int
+	// Units redeemed since the start of the week
+	week int
+	last time.Time
+}
+
+func nextAvailable(clock period.Clock, reward *core.Record, usage limitUsage, now time.Time) (time.Time, error) {
+	next := now
+
+	if stock := reward.GetInt("stock"); stock > 0 && usage.total >= stock {
+		return time.Time{}, nil
+	}
+
+	if cooldown := reward.GetInt("cooldown"); cooldown > 0 {
+		if until := usage.last.Add(time.Duration(cooldown) * time.Minute); until.After(next) {
+			next = until
+		}
+	}
+
+	if weeklyCap := reward.GetInt("weekly_cap"); weeklyCap > 0 && usage.week >= weeklyCap {
+		_, end, err := clock.Bounds(period.Weekly, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if end.After(next) {
+			next = end
+		}
+	}
+
+	return next, nil
+}
+
+// loadUsage sums the usage of the rewards, their weeks starting at the given
+// times.
+func loadUsage(app core.App, records []*core.Record, weeks map[string]time.Time) (map[string]limitUsage, error) {
+	usage := make(map[string]limitUsage, len(records))
+	if len(records) == 0 {
+		return usage, nil
+	}
+
+	ids := make([]any, 0, len(records))
+	from := time.Time{}
+	for _, reward := range records {
+		ids = append(ids, reward.Id)
+
+		if week := weeks[reward.Id]; from.IsZero() || week.Before(from) {
+			from = week
+		}
+	}
+
+	var totals []struct {
+		Reward string `db:"reward"`
+		Total  int    `db:"total"`
+		Last   string `db:"last"`
+	}
+
+	err := app.DB().
+		Select(
+			"reward",
+			"COALESCE(SUM(quantity - refunded), 0) AS total",
+			"COALESCE(MAX(CASE WHEN refunded < quantity THEN redeemed_at END), '') AS last",
+		).
+		From(RedemptionsCollection).
+		Where(dbx.In("reward", ids...)).
+		GroupBy("reward").
+		All(&totals)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range totals {
+		last, err := types.ParseDateTime(row.Last)
+		if err != nil {
+			return nil, err
+		}
+
+		usage[row.Reward] = limitUsage{total: row.Total, last: last.Time()}
+	}
+
+	// The redemptions of the earliest week, split by the week of each reward
+	var recent []struct {
+		Reward     string `db:"reward"`
+		RedeemedAt string `db:"redeemed_at"`
+		Units      int    `db:"units"`
+	}
+
+	err = app.DB().
+		Select("reward", "redeemed_at", "(quantity - refunded) AS units").
+		From(RedemptionsCollection).
+		Where(dbx.In("reward", ids...)).
+		AndWhere(dbx.NewExp(
+			"redeemed_at >= {:from}",
+			dbx.Params{"from": from.UTC().Format(types.DefaultDateLayout)},
+		)).
+		All(&recent)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range recent {
+		redeemedAt, err := types.ParseDateTime(row.RedeemedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if !redeemedAt.Time().Before(weeks[row.Reward]) {
+			u := usage[row.Reward]
+			u.week += row.Units
+			usage[row.Reward] = u
+		}
+	}
+
+	return usage, nil
+}
+
+// countRedeemed sums the units of the reward redeemed since from and not
+// refunded.
+func countRedeemed(app core.App, reward *core.Record, from time.Time) (int, error) {
+	var result struct {
+		Total int `db:"total"`
+	}
+
+	query := app.DB().
+		Select("COALESCE(SUM(quantity - refunded), 0) AS total").
+		From(RedemptionsCollection).
+		Where(dbx.HashExp{"reward": reward.Id})
+
+	if !from.IsZero() {
+		query.AndWhere(dbx.NewExp(
+			"redeemed_at >= {:from}",
+			dbx.Params{"from": from.UTC().Format(types.DefaultDateLayout)},
+		))
+	}
+
+	err := query.One(&result)
+
+	return result.Total, err
+}
+
+// lastRedeemed returns when the reward was last redeemed, ignoring fully
+// refunded redemptions.
+func lastRedeemed(app core.App, reward *core.Record) (time.Time, error) {
+	records, err := app.FindRecordsByFilter(
+		RedemptionsCollection,
+		"reward = {:reward} && refunded < quantity",
+		"-redeemed_at",
+		1,
+		0,
+		dbx.Params{"reward": reward.Id},
+	)
+	if err != nil || len(records) == 0 {
+		return time.Time{}, err
+	}
+
+	return records[0].GetDateTime("redeemed_at").Time(), nil
+}
@@ -0,0 +1,79 @@
+package rewards
+
+import (
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const RedemptionsCollection = "redemptions"
+
+// SaveRedemption records that quantity units of the reward were redeemed by
+// user for cost points, saved of them taken from the reward savings.
+func SaveRedemption(
+	app core.App,
+	reward *core.Record,
+	user *core.Record,
+	quantity int,
+	cost int,
+	saved int,
+	now time.Time,
+) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId(RedemptionsCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	redemption := core.NewRecord(collection)
+	redemption.Set("reward", reward.Id)
+	redemption.Set("user", user.Id)
+	redemption.Set("quantity", quantity)
+	redemption.Set("cost", cost)
+	redemption.Set("saved", saved)
+	redemption.Set("redeemed_at", now)
+
+	return redemption, app.Save(redemption)
+}
+
+// UseRedemptions marks quantity units of the reward as used, oldest
+// redemptions first. Only the redemptions since the last reset count, like the
+// redeemed counter of the reward.
+func UseRedemptions(app core.App, reward *core.Record, quantity int, now time.Time) error {
+	var records []*core.Record
+	err := app.RecordQuery(RedemptionsCollection).
+		AndWhere(dbx.HashExp{"reward": reward.Id}).
+		AndWhere(dbx.NewExp("used + refunded < quantity")).
+		AndWhere(dbx.NewExp(
+			"redeemed_at >= {:reset}",
+			dbx.Params{"reset": reward.GetDateTime("last_reset").String()},
+		)).
+		OrderBy("redeemed_at ASC", "id ASC").
+		All(&records)
+	if err != nil {
+		return err
+	}
+
+	for _, redemption := range records {
+		if quantity <= 0 {
+			break
+		}
+
+		used := min(quantity, Unused(redemption))
+		quantity -= used
+
+		redemption.Set("used", redemption.GetInt("used")+used)
+		redemption.Set("used_at", now)
+		if err := app.Save(redemption); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Unused returns how many units of the redemption were neither used nor
+// refunded.
+func Unused(redemption *core.Record) int {
+	return redemption.GetInt("quantity") - redemption.GetInt("used") - redemption.GetInt("refunded")
+}
@@ -0,0 +1,79 @@
+package rewards
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/period"
+	"github.com/dr4ghs/orgtool/recurrence"
+)
+
+const Collection = "rewards"
+
+const (
+	ResetNever   = "never"
+	ResetDaily   = "daily"
+	ResetWeekly  = "weekly"
+	ResetMonthly = "monthly"
+	ResetCustom  = "custom"
+)
+
+var ResetPeriods = []string{ResetNever, ResetDaily, ResetWeekly, ResetMonthly, ResetCustom}
+
+// ResetRule parses the rule of a custom reset period.
+func ResetRule(reward *core.Record) (*recurrence.Rule, error) {
+	rule, err := recurrence.Parse(reward.GetString("reset_rule"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid reward reset rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// WindowStart returns when the reset window containing now began. A zero time
+// means the reward is never reset.
+func WindowStart(clock period.Clock, reward *core.Record, now time.Time) (time.Time, error) {
+	switch typ := reward.GetString("reset_period"); typ {
+	case ResetNever, "":
+		return time.Time{}, nil
+	case ResetDaily, ResetWeekly, ResetMonthly:
+		start, _, err := clock.Bounds(typ, now)
+		return start, err
+	case ResetCustom:
+		rule, err := ResetRule(reward)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		// The rule starts with the day the reward was created in
+		day, ok := rule.Prev(clock.Day(reward.GetDateTime("created").Time()), clock.Day(now))
+		if !ok {
+			return time.Time{}, nil
+		}
+
+		return clock.StartOf(day), nil
+	default:
+		return time.Time{}, fmt.Errorf("Not known reset period '%s'", typ)
+	}
+}
+
+// Reset clears the redeemed and used counters when a new reset window began
+// since the last reset.
+func Reset(app core.App, clock period.Clock, reward *core.Record, now time.Time) (bool, error) {
+	start, err := WindowStart(clock, reward, now)
+	if err != nil || start.IsZero() {
+		return false, err
+	}
+
+	if !reward.GetDateTime("last_reset").Time().Before(start) {
+		return false, nil
+	}
+
+	reward.Set("redeemed", 0)
+	reward.Set("used", 0)
+	reward.Set("last_reset", now)
+
+	return true, app.Save(reward)
+}
@@ -0,0 +1,98 @@
+package rewards
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/ledger"
+	"github.com/dr4ghs/orgtool/period"
+)
+
+var (
+	ErrAmount          = errors.New("The amount cannot be zero")
+	ErrNotEnoughFree   = errors.New("Not enough available points to save")
+	ErrNotEnoughSaved  = errors.New("Not enough saved points to release")
+	ErrSavedNotAllowed = errors.New("Saved points can only be changed through the save action")
+)
+
+// Available returns the points of the user that aren't locked in a reward.
+func Available(user *core.Record) int {
+	return user.GetInt("points") - user.GetInt("locked_points")
+}
+
+// Save locks amount of the owner's available points into the reward. A
+// negative amount releases saved points back.
+func Save(app core.App, rewardId string, amount int) (reward *core.Record, user *core.Record, err error) {
+	if amount == 0 {
+		return nil, nil, ErrAmount
+	}
+
+	err = app.RunInTransaction(func(txApp core.App) error {
+		reward, err = txApp.FindRecordById(Collection, rewardId)
+		if err != nil {
+			return err
+		}
+
+		user, err = txApp.FindRecordById("users", reward.GetString("user"))
+		if err != nil {
+			return err
+		}
+
+		if amount > Available(user) {
+			return ErrNotEnoughFree
+		}
+
+		if reward.GetInt("saved")+amount < 0 {
+			return ErrNotEnoughSaved
+		}
+
+		user.Set("locked_points", user.GetInt("locked_points")+amount)
+		if err := txApp.Save(user); err != nil {
+			return err
+		}
+
+		reward.Set("saved", reward.GetInt("saved")+amount)
+
+		return txApp.Save(reward)
+	})
+
+	return
+}
+
+// Charge checks the reward limits, debits quantity units of the reward from
+// user, using the points saved in the reward first, and records the
+// redemption. The reward is updated but not saved, it has to run in the
+// caller's transaction.
+func Charge(app core.App, reward *core.Record, user *core.Record, quantity int) (cost int, err error) {
+	if err := CheckLimits(app, period.UserClock(user), reward, quantity, time.Now()); err != nil {
+		return 0, err
+	}
+
+	cost = quantity * reward.GetInt("unit_cost")
+
+	// Only the owner saves into the reward
+	saved := 0
+	if user.Id == reward.GetString("user") {
+		saved = min(reward.GetInt("saved"), cost)
+	}
+
+	if Available(user) < cost-saved {
+		return 0, ErrNotEnoughPoints
+	}
+
+	user.Set("locked_points", user.GetInt("locked_points")-saved)
+	reward.Set("saved", reward.GetInt("saved")-saved)
+
+	// Saves the user as well
+	if _, err := ledger.Record(app, user, -cost, ledger.ReasonRedemption, reward); err != nil {
+		return 0, err
+	}
+
+	if _, err := SaveRedemption(app, reward, user, quantity, cost, saved, time.Now()); err != nil {
+		return 0, err
+	}
+
+	return cost, nil
+}
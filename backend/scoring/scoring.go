@@ -0,0 +1,118 @@
+package scoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Mode string
+
+const (
+	AllOrNothing Mode = "all_or_nothing"
+	Proportional Mode = "proportional"
+	Tiered       Mode = "tiered"
+)
+
+var Modes = []string{string(AllOrNothing), string(Proportional), string(Tiered)}
+
+// Tier pays Award percent of the activity points once At percent of the goal
+// is reached. Tiers past 100% reward over-achievement.
+type Tier struct {
+	At    int `json:"at"`
+	Award int `json:"award"`
+}
+
+var DefaultTiers = []Tier{
+	{At: 50, Award: 50},
+	{At: 100, Award: 100},
+	{At: 150, Award: 150},
+}
+
+type Rules struct {
+	Mode  Mode
+	Tiers []Tier
+	// Highest percent of the points proportional scoring pays
+	Cap int
+}
+
+func ActivityRules(activity *core.Record) (Rules, error) {
+	rules := Rules{
+		Mode: Mode(activity.GetString("scoring")),
+		Cap:  activity.GetInt("scoring_cap"),
+	}
+
+	if rules.Mode == "" {
+		rules.Mode = AllOrNothing
+	}
+
+	if rules.Cap <= 0 {
+		rules.Cap = 100
+	}
+
+	if raw := activity.GetString("tiers"); raw != "" && raw != "null" {
+		if err := json.Unmarshal([]byte(raw), &rules.Tiers); err != nil {
+			return rules, fmt.Errorf("Invalid scoring tiers: %w", err)
+		}
+	}
+
+	if rules.Mode == Tiered && len(rules.Tiers) == 0 {
+		rules.Tiers = DefaultTiers
+	}
+
+	return rules, rules.Validate()
+}
+
+func (r Rules) Validate() error {
+	switch r.Mode {
+	case AllOrNothing, Proportional, Tiered:
+	default:
+		return fmt.Errorf("Unknown scoring mode '%s'", r.Mode)
+	}
+
+	if r.Cap < 100 {
+		return fmt.Errorf("The scoring cap cannot be lower than 100%%")
+	}
+
+	for _, tier := range r.Tiers {
+		if tier.At <= 0 || tier.Award < 0 {
+			return fmt.Errorf("Invalid scoring tier %d%%: %d%%", tier.At, tier.Award)
+		}
+	}
+
+	return nil
+}
+
+// Score returns the points earned for reaching progress out of goal.
+func (r Rules) Score(points int, progress int, goal int) int {
+	if goal <= 0 {
+		return points
+	}
+
+	switch r.Mode {
+	case Proportional:
+		// Percents of the goal scaled by it, so the math stays in integers
+		achieved := min(progress*100, r.Cap*goal)
+		return points * achieved / (100 * goal)
+	case Tiered:
+		tiers := slices.Clone(r.Tiers)
+		slices.SortFunc(tiers, func(a Tier, b Tier) int { return a.At - b.At })
+
+		award := 0
+		for _, tier := range tiers {
+			if progress*100 >= tier.At*goal {
+				award = tier.Award
+			}
+		}
+
+		return points * award / 100
+	}
+
+	if progress >= goal {
+		return points
+	}
+
+	return 0
+}
@@ -0,0 +1,40 @@
+package scoring
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	cases := []struct {
+		name     string
+		rules    Rules
+		points   int
+		progress int
+		goal     int
+		expected int
+	}{
+		{"all or nothing short", Rules{Mode: AllOrNothing}, 10, 99, 100, 0},
+		{"all or nothing reached", Rules{Mode: AllOrNothing}, 10, 100, 100, 10},
+		{"all or nothing over", Rules{Mode: AllOrNothing}, 10, 250, 100, 10},
+		{"all or nothing no goal", Rules{Mode: AllOrNothing}, 10, 0, 0, 10},
+		{"proportional none", Rules{Mode: Proportional, Cap: 100}, 10, 0, 3, 0},
+		{"proportional floored", Rules{Mode: Proportional, Cap: 100}, 10, 2, 3, 6},
+		{"proportional reached", Rules{Mode: Proportional, Cap: 100}, 10, 3, 3, 10},
+		{"proportional over capped", Rules{Mode: Proportional, Cap: 100}, 10, 5, 3, 10},
+		{"proportional over", Rules{Mode: Proportional, Cap: 200}, 10, 4, 3, 13},
+		{"proportional over the cap", Rules{Mode: Proportional, Cap: 150}, 10, 9, 3, 15},
+		{"tiered below the first", Rules{Mode: Tiered, Tiers: DefaultTiers}, 10, 49, 100, 0},
+		{"tiered first", Rules{Mode: Tiered, Tiers: DefaultTiers}, 10, 50, 100, 5},
+		{"tiered goal", Rules{Mode: Tiered, Tiers: DefaultTiers}, 10, 100, 100, 10},
+		{"tiered over", Rules{Mode: Tiered, Tiers: DefaultTiers}, 10, 150, 100, 15},
+		{"tiered past the last", Rules{Mode: Tiered, Tiers: DefaultTiers}, 10, 400, 100, 15},
+		{"tiered boundary", Rules{Mode: Tiered, Tiers: []Tier{{At: 29, Award: 50}}}, 10, 29, 100, 5},
+		{"tiered below the boundary", Rules{Mode: Tiered, Tiers: []Tier{{At: 29, Award: 50}}}, 10, 28, 100, 0},
+		{"tiered third", Rules{Mode: Tiered, Tiers: []Tier{{At: 33, Award: 50}}}, 10, 1, 3, 5},
+		{"tiered unsorted", Rules{Mode: Tiered, Tiers: []Tier{{At: 100, Award: 100}, {At: 50, Award: 40}}}, 10, 60, 100, 4},
+	}
+
+	for _, c := range cases {
+		if score := c.rules.Score(c.points, c.progress, c.goal); score != c.expected {
+			t.Errorf("%s: scored %d, expected %d", c.name, score, c.expected)
+		}
+	}
+}
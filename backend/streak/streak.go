@@ -0,0 +1,71 @@
+package streak
+
+import (
+	"math"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/period"
+)
+
+// Schedule grants Bonus percent more points every Step consecutive met
+// periods, up to Cap percent.
+type Schedule struct {
+	Step  int
+	Bonus int
+	Cap   int
+}
+
+// Default schedules, roughly a step per week of effort
+var Defaults = map[string]Schedule{
+	period.Daily:   {Step: 7, Bonus: 10, Cap: 50},
+	period.Weekly:  {Step: 4, Bonus: 10, Cap: 50},
+	period.Monthly: {Step: 3, Bonus: 10, Cap: 50},
+	period.Yearly:  {Step: 1, Bonus: 10, Cap: 50},
+}
+
+// ActivitySchedule returns the schedule of the activity period type with the
+// activity overrides applied. Zero values keep the default.
+func ActivitySchedule(activity *core.Record) Schedule {
+	schedule := Defaults[activity.GetString("type")]
+
+	if step := activity.GetInt("streak_step"); step > 0 {
+		schedule.Step = step
+	}
+
+	if bonus := activity.GetInt("streak_bonus"); bonus > 0 {
+		schedule.Bonus = bonus
+	}
+
+	if limit := activity.GetInt("streak_cap"); limit > 0 {
+		schedule.Cap = limit
+	}
+
+	return schedule
+}
+
+func (s Schedule) Multiplier(streak int) float64 {
+	if s.Step <= 0 {
+		return 1
+	}
+
+	bonus := min((streak/s.Step)*s.Bonus, s.Cap)
+
+	return 1 + float64(bonus)/100
+}
+
+func (s Schedule) Apply(points int, streak int) int {
+	return int(math.Round(float64(points) * s.Multiplier(streak)))
+}
+
+// Advance updates the activity streaks after one of its periods closed.
+func Advance(activity *core.Record, met bool) {
+	if !met {
+		activity.Set("streak", 0)
+		return
+	}
+
+	current := activity.GetInt("streak") + 1
+	activity.Set("streak", current)
+	activity.Set("best_streak", max(current, activity.GetInt("best_streak")))
+}
@@ -0,0 +1,104 @@
+package streak
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"github.com/dr4ghs/orgtool/period"
+)
+
+func newActivity(typ string) *core.Record {
+	collection := core.NewBaseCollection("activities")
+	collection.Fields.Add(
+		&core.TextField{Name: "type"},
+		&core.NumberField{Name: "streak"},
+		&core.NumberField{Name: "best_streak"},
+		&core.NumberField{Name: "streak_step"},
+		&core.NumberField{Name: "streak_bonus"},
+		&core.NumberField{Name: "streak_cap"},
+	)
+
+	activity := core.NewRecord(collection)
+	activity.Set("type", typ)
+
+	return activity
+}
+
+func TestAdvanceBreaks(t *testing.T) {
+	activity := newActivity(period.Daily)
+
+	steps := []struct {
+		met    bool
+		streak int
+		best   int
+	}{
+		{true, 1, 1},
+		{true, 2, 2},
+		{true, 3, 3},
+		{false, 0, 3},
+		{true, 1, 3},
+		{false, 0, 3},
+		{false, 0, 3},
+		{true, 1, 3},
+		{true, 2, 3},
+		{true, 3, 3},
+		{true, 4, 4},
+	}
+
+	for i, step := range steps {
+		Advance(activity, step.met)
+
+		if activity.GetInt("streak") != step.streak || activity.GetInt("best_streak") != step.best {
+			t.Fatalf(
+				"Step %d: streak %d, best %d, expected %d, %d",
+				i, activity.GetInt("streak"), activity.GetInt("best_streak"), step.streak, step.best,
+			)
+		}
+	}
+}
+
+func TestScheduleByPeriodType(t *testing.T) {
+	cases := []struct {
+		typ      string
+		streak   int
+		expected int
+	}{
+		{period.Daily, 6, 100},
+		{period.Daily, 7, 110},
+		{period.Daily, 20, 120},
+		{period.Daily, 365, 150},
+		{period.Weekly, 3, 100},
+		{period.Weekly, 4, 110},
+		{period.Weekly, 8, 120},
+		{period.Monthly, 2, 100},
+		{period.Monthly, 3, 110},
+		{period.Yearly, 1, 110},
+		{period.Yearly, 4, 140},
+		{period.Yearly, 10, 150},
+	}
+
+	for _, c := range cases {
+		if got := ActivitySchedule(newActivity(c.typ)).Apply(100, c.streak); got != c.expected {
+			t.Errorf("%s streak of %d gives %d points, expected %d", c.typ, c.streak, got, c.expected)
+		}
+	}
+}
+
+func TestScheduleOverrides(t *testing.T) {
+	activity := newActivity(period.Daily)
+	activity.Set("streak_step", 2)
+	activity.Set("streak_bonus", 25)
+	activity.Set("streak_cap", 60)
+
+	schedule := ActivitySchedule(activity)
+	if schedule != (Schedule{Step: 2, Bonus: 25, Cap: 60}) {
+		t.Fatalf("Schedule is %+v", schedule)
+	}
+
+	for streak, expected := range map[int]int{0: 10, 1: 10, 2: 13, 4: 15, 10: 16} {
+		if got := schedule.Apply(10, streak); got != expected {
+			t.Errorf("Streak of %d gives %d points, expected %d", streak, got, expected)
+		}
+	}
+}
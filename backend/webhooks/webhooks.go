@@ -0,0 +1,319 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	Collection           = "webhooks"
+	DeliveriesCollection = "webhook_deliveries"
+)
+
+const (
+	EventEntryCompleted = "entry.completed"
+	EventPeriodClosed   = "period.closed"
+	EventPointsAwarded  = "points.awarded"
+	EventRewardRedeemed = "reward.redeemed"
+)
+
+var Events = []string{EventEntryCompleted, EventPeriodClosed, EventPointsAwarded, EventRewardRedeemed}
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+var Statuses = []string{StatusPending, StatusDelivered, StatusFailed}
+
+const (
+	// Attempts before a delivery is given up
+	MaxAttempts = 8
+	// Wait after the first failure, doubled on every following one
+	BaseDelay = 30 * time.Second
+	MaxDelay  = 6 * time.Hour
+
+	// Deliveries sent by a single run
+	batchSize = 50
+	// A delivery being sent is not picked again for this long, in case the
+	// next run starts before this one is over
+	claimTimeout = 5 * time.Minute
+)
+
+const (
+	HeaderEvent     = "X-Orgtool-Event"
+	HeaderDelivery  = "X-Orgtool-Delivery"
+	HeaderTimestamp = "X-Orgtool-Timestamp"
+	HeaderSignature = "X-Orgtool-Signature"
+)
+
+var ErrURL = errors.New("The webhook URL must be a public http or https address")
+
+// Client refuses to connect to private addresses, even when the host resolves
+// to a different one than it did when the URL was checked.
+var Client = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network string, address string, c syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+
+				if ip := net.ParseIP(host); ip == nil || !allowedIP(ip) {
+					return ErrURL
+				}
+
+				return nil
+			},
+		}).DialContext,
+	},
+}
+
+// allowedIP reports whether webhooks can be sent to ip, swapped in the tests
+// to reach local servers.
+var allowedIP = func(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// Payload is the body posted to the webhooks.
+type Payload struct {
+	Id      string `json:"id"`
+	Event   string `json:"event"`
+	User    string `json:"user"`
+	Created string `json:"created"`
+	Data    any    `json:"data"`
+}
+
+// Emit queues a delivery of the event for each active webhook of the user
+// subscribed to it. Run within a transaction the deliveries are queued only
+// if it commits.
+func Emit(app core.App, userId string, event string, data any) error {
+	hooks, err := app.FindAllRecords(
+		Collection,
+		dbx.HashExp{"user": userId, "active": true},
+	)
+	if err != nil {
+		return err
+	}
+
+	collection, err := app.FindCollectionByNameOrId(DeliveriesCollection)
+	if err != nil {
+		return err
+	}
+
+	now := types.NowDateTime()
+	for _, hook := range hooks {
+		// No events selected means all of them
+		events := hook.GetStringSlice("events")
+		if len(events) > 0 && !slices.Contains(events, event) {
+			continue
+		}
+
+		delivery := core.NewRecord(collection)
+		delivery.Id = core.GenerateDefaultRandomId()
+
+		body, err := json.Marshal(Payload{
+			Id:      delivery.Id,
+			Event:   event,
+			User:    userId,
+			Created: now.String(),
+			Data:    data,
+		})
+		if err != nil {
+			return err
+		}
+
+		delivery.Set("webhook", hook.Id)
+		delivery.Set("event", event)
+		delivery.Set("payload", types.JSONRaw(body))
+		delivery.Set("status", StatusPending)
+		delivery.Set("attempts", 0)
+		delivery.Set("next_attempt_at", now)
+		if err := app.Save(delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deliver sends the pending deliveries that are due, returning how many
+// were delivered.
+func Deliver(app core.App, client *http.Client, now time.Time) (delivered int, err error) {
+	pending, err := app.FindRecordsByFilter(
+		DeliveriesCollection,
+		"status = {:status} && next_attempt_at <= {:now}",
+		"next_attempt_at,created",
+		batchSize,
+		0,
+		dbx.Params{
+			"status": StatusPending,
+			"now":    now.UTC().Format(types.DefaultDateLayout),
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range pending {
+		delivery.Set("next_attempt_at", now.Add(claimTimeout))
+		if err := app.Save(delivery); err != nil {
+			return delivered, err
+		}
+
+		code, sendErr := send(app, client, delivery, now)
+		if err := result(app, delivery, code, sendErr, now); err != nil {
+			return delivered, err
+		}
+
+		if sendErr == nil {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// Sign returns the signature of the body sent at timestamp: the hex HMAC-SHA256
+// of "timestamp.body" keyed with the webhook secret.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckURL returns ErrURL unless raw is an http or https URL whose host only
+// resolves to public addresses.
+func CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrURL
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(context.Background(), "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("Unable to resolve the webhook host: %w", err)
+	}
+
+	for _, ip := range ips {
+		if !allowedIP(ip) {
+			return ErrURL
+		}
+	}
+
+	return nil
+}
+
+// Backoff returns the wait before the next attempt after the given number of
+// failed ones.
+func Backoff(attempts int) time.Duration {
+	delay := BaseDelay
+	for i := 1; i < attempts && delay < MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, MaxDelay)
+}
+
+// =============================================================================
+// HELPERS
+//
+
+func send(app core.App, client *http.Client, delivery *core.Record, now time.Time) (int, error) {
+	hook, err := app.FindRecordById(Collection, delivery.GetString("webhook"))
+	if err != nil {
+		return 0, err
+	}
+
+	if !hook.GetBool("active") {
+		return 0, fmt.Errorf("The webhook is disabled")
+	}
+
+	// The URL may predate the check or point somewhere else by now
+	if err := CheckURL(hook.GetString("url")); err != nil {
+		return 0, err
+	}
+
+	body := []byte(delivery.GetString("payload"))
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.GetString("url"), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "orgtool-webhooks")
+	req.Header.Set(HeaderEvent, delivery.GetString("event"))
+	req.Header.Set(HeaderDelivery, delivery.Id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(hook.GetString("secret"), timestamp, body))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// Drained so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("Unexpected response status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+func result(app core.App, delivery *core.Record, code int, sendErr error, now time.Time) error {
+	attempts := delivery.GetInt("attempts") + 1
+
+	delivery.Set("attempts", attempts)
+	delivery.Set("last_attempt_at", now)
+	delivery.Set("response_status", code)
+
+	switch {
+	case sendErr == nil:
+		delivery.Set("status", StatusDelivered)
+		delivery.Set("error", "")
+	case attempts >= MaxAttempts:
+		delivery.Set("status", StatusFailed)
+		delivery.Set("error", sendErr.Error())
+	default:
+		delivery.Set("next_attempt_at", now.Add(Backoff(attempts)))
+		delivery.Set("error", sendErr.Error())
+	}
+
+	return app.Save(delivery)
+}
@@ -0,0 +1,235 @@
+package webhooks
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+
+	_ "github.com/dr4ghs/orgtool/migrations"
+)
+
+const testSecret = "secretsecretsecret"
+
+func TestCheckURL(t *testing.T) {
+	cases := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://203.0.113.10/hook", true},
+		{"http://203.0.113.10:8080/hook?x=1", true},
+		{"ftp://203.0.113.10/hook", false},
+		{"file:///etc/passwd", false},
+		{"https://", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://10.1.2.3/hook", false},
+		{"http://172.16.0.1/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[fe80::1]/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://224.0.0.1/hook", false},
+	}
+
+	for _, c := range cases {
+		err := CheckURL(c.url)
+		if c.valid && err != nil {
+			t.Errorf("CheckURL(%q) = %v, expected no error", c.url, err)
+		}
+		if !c.valid && !errors.Is(err, ErrURL) {
+			t.Errorf("CheckURL(%q) = %v, expected ErrURL", c.url, err)
+		}
+	}
+}
+
+// allowLoopback lets the deliveries reach the local test server.
+func allowLoopback(t *testing.T) {
+	public := allowedIP
+	allowedIP = func(ip net.IP) bool { return ip.IsLoopback() || public(ip) }
+	t.Cleanup(func() { allowedIP = public })
+}
+
+func newWebhook(t *testing.T, url string) (*tests.TestApp, *core.Record) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := core.NewRecord(users)
+	user.SetEmail("user@example.com")
+	user.SetPassword("1234567890")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+
+	collection, err := app.FindCollectionByNameOrId(Collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook := core.NewRecord(collection)
+	hook.Set("user", user.Id)
+	hook.Set("url", url)
+	hook.Set("secret", testSecret)
+	hook.Set("active", true)
+	if err := app.Save(hook); err != nil {
+		t.Fatal(err)
+	}
+
+	return app, user
+}
+
+func delivery(t *testing.T, app core.App) *core.Record {
+	list, err := app.FindAllRecords(DeliveriesCollection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(list))
+	}
+
+	return list[0]
+}
+
+func TestDeliverSigned(t *testing.T) {
+	allowLoopback(t)
+
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if Sign(testSecret, r.Header.Get(HeaderTimestamp), body) != r.Header.Get(HeaderSignature) {
+			t.Error("Invalid signature")
+		}
+		if r.Header.Get(HeaderEvent) != EventPointsAwarded {
+			t.Errorf("Unexpected event %q", r.Header.Get(HeaderEvent))
+		}
+		received++
+	}))
+	defer server.Close()
+
+	app, user := newWebhook(t, server.URL)
+	if err := Emit(app, user.Id, EventPointsAwarded, map[string]any{"delta": 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	delivered, err := Deliver(app, server.Client(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if delivered != 1 || received != 1 {
+		t.Fatalf("Delivered %d, received %d, expected 1", delivered, received)
+	}
+
+	if d := delivery(t, app); d.GetString("status") != StatusDelivered || d.GetInt("response_status") != 200 {
+		t.Errorf("Delivery is %s with status %d", d.GetString("status"), d.GetInt("response_status"))
+	}
+
+	// Nothing left to send
+	if delivered, err := Deliver(app, server.Client(), time.Now()); err != nil || delivered != 0 {
+		t.Errorf("Second run delivered %d, %v", delivered, err)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	allowLoopback(t)
+
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	app, user := newWebhook(t, server.URL)
+	if err := Emit(app, user.Id, EventPointsAwarded, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		if _, err := Deliver(app, server.Client(), now); err != nil {
+			t.Fatal(err)
+		}
+
+		d := delivery(t, app)
+		if d.GetInt("attempts") != attempt || d.GetInt("response_status") != 500 {
+			t.Fatalf("Attempt %d recorded as %d with status %d", attempt, d.GetInt("attempts"), d.GetInt("response_status"))
+		}
+
+		if attempt == MaxAttempts {
+			if d.GetString("status") != StatusFailed {
+				t.Fatalf("Delivery is %s after the last attempt", d.GetString("status"))
+			}
+			break
+		}
+
+		next := d.GetDateTime("next_attempt_at").Time()
+		if wait := next.Sub(now); wait.Round(time.Second) != Backoff(attempt) {
+			t.Fatalf("Attempt %d waits %v, expected %v", attempt, wait, Backoff(attempt))
+		}
+
+		// Not due yet
+		if _, err := Deliver(app, server.Client(), next.Add(-time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		if received != attempt {
+			t.Fatalf("Sent before the backoff was over")
+		}
+
+		now = next
+	}
+
+	if received != MaxAttempts {
+		t.Errorf("Received %d attempts, expected %d", received, MaxAttempts)
+	}
+}
+
+func TestDeliverRefusesPrivateURL(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	app, user := newWebhook(t, server.URL)
+	if err := Emit(app, user.Id, EventPointsAwarded, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Deliver(app, server.Client(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if received != 0 {
+		t.Fatal("The loopback server was reached")
+	}
+
+	if d := delivery(t, app); d.GetString("error") != ErrURL.Error() {
+		t.Errorf("Delivery error is %q", d.GetString("error"))
+	}
+}
+
+func TestClientRefusesPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := Client.Get(server.URL)
+	if !errors.Is(err, ErrURL) {
+		t.Errorf("Client reached %s: %v", server.URL, err)
+	}
+}